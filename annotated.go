@@ -20,6 +20,15 @@
 
 package fx
 
+import (
+	"fmt"
+	"reflect"
+
+	"go.uber.org/dig"
+
+	"go.uber.org/fx/internal/fxreflect"
+)
+
 // Annotated annotates a constructor provided to Fx with additional options.
 //
 // For example,
@@ -61,4 +70,253 @@ type Annotated struct {
 
 	// Target is the constructor being annotated with fx.Annotated.
 	Target interface{}
+
+	// If set, Lifecycle hooks are appended for the values returned by the
+	// constructor, as with the AutoLifecycle option.
+	AutoLifecycle bool
+
+	// If specified, the values returned by the constructor are also provided
+	// as each of these interfaces, given as pointers to them. For example,
+	//
+	//   fx.Provide(fx.Annotated{
+	//     Target: NewPostgresStore, // returns *PostgresStore
+	//     As:     []interface{}{new(UserStore)},
+	//   })
+	//
+	// provides both *PostgresStore and UserStore. If Name is specified, it
+	// applies to the interfaces too. Every value returned by the constructor
+	// must implement every interface.
+	//
+	// As may not be combined with Group, nor used with constructors which
+	// produce fx.Out objects.
+	As []interface{}
+
+	// If specified, these struct tags are attached to the constructor's
+	// parameters, in order, as if the constructor took an fx.In object with
+	// one field per parameter. Tags may be empty for parameters that don't
+	// need any. For example,
+	//
+	//   fx.Provide(fx.Annotated{
+	//     Target:    NewUserService, // func(*sql.DB, *log.Logger) *UserService
+	//     ParamTags: []string{`name:"ro"`, `optional:"true"`},
+	//   })
+	//
+	// makes NewUserService use the *sql.DB named "ro", and nil if there is
+	// no *log.Logger. ParamTags may not be used with constructors which take
+	// fx.In objects.
+	ParamTags []string
+
+	// If specified, these struct tags are attached to the values returned by
+	// the constructor, in order, as if it returned an fx.Out object with one
+	// field per value. The error, if any, is left out. For example,
+	//
+	//   fx.Provide(fx.Annotated{
+	//     Target:     NewConnections, // func() (*sql.DB, *sql.DB, error)
+	//     ResultTags: []string{`name:"ro"`, `name:"rw"`},
+	//   })
+	//
+	// ResultTags may not be combined with Name, Group, or As, nor used with
+	// constructors which produce fx.Out objects.
+	ResultTags []string
+}
+
+// build returns the constructor to provide for a: a.Target, or a function
+// generated to apply a.ParamTags and a.ResultTags to it.
+func (a Annotated) build() (interface{}, error) {
+	if len(a.ParamTags) == 0 && len(a.ResultTags) == 0 {
+		return a.Target, nil
+	}
+
+	fv := reflect.ValueOf(a.Target)
+	ft := fv.Type()
+	if ft == nil || ft.Kind() != reflect.Func {
+		return nil, fmt.Errorf("fx.Annotated tags require a function Target: got %v", ft)
+	}
+	if len(a.ResultTags) > 0 && (len(a.Name) > 0 || len(a.Group) > 0 || len(a.As) > 0) {
+		return nil, fmt.Errorf("fx.Annotated may not specify ResultTags along with Name, Group, or As for %v", fxreflect.FuncName(a.Target))
+	}
+
+	ins, err := a.paramTypes(ft)
+	if err != nil {
+		return nil, err
+	}
+	outs, errIndex, err := a.resultTypes(ft)
+	if err != nil {
+		return nil, err
+	}
+
+	// Equivalent to,
+	//
+	// 	func(p struct {
+	// 		fx.In
+	//
+	// 		F0 A `name:"ro"`
+	// 		F1 B
+	// 	}) (struct {
+	// 		fx.Out
+	//
+	// 		F0 C `group:"servers"`
+	// 	}, error) {
+	// 		c, err := target(p.F0, p.F1)
+	// 		return struct{...}{F0: c}, err
+	// 	}
+	fn := reflect.MakeFunc(
+		reflect.FuncOf(ins, outs, len(a.ParamTags) == 0 && ft.IsVariadic()),
+		func(args []reflect.Value) []reflect.Value {
+			if len(a.ParamTags) > 0 {
+				p := args[0]
+				args = make([]reflect.Value, ft.NumIn())
+				for i := range args {
+					args[i] = p.Field(i + 1)
+				}
+			}
+
+			var results []reflect.Value
+			if ft.IsVariadic() {
+				results = fv.CallSlice(args)
+			} else {
+				results = fv.Call(args)
+			}
+			if len(a.ResultTags) == 0 {
+				return results
+			}
+
+			r := reflect.New(outs[0]).Elem()
+			out := []reflect.Value{r}
+			for i, v := range results {
+				if i == errIndex {
+					out = append(out, v)
+					continue
+				}
+				r.Field(i + 1).Set(v)
+			}
+			return out
+		},
+	)
+	return fn.Interface(), nil
+}
+
+// paramTypes returns the parameter types of the function generated for a,
+// whose Target has type ft.
+func (a Annotated) paramTypes(ft reflect.Type) ([]reflect.Type, error) {
+	if len(a.ParamTags) == 0 {
+		ins := make([]reflect.Type, ft.NumIn())
+		for i := range ins {
+			ins[i] = ft.In(i)
+		}
+		return ins, nil
+	}
+
+	if len(a.ParamTags) > ft.NumIn() {
+		return nil, fmt.Errorf("fx.Annotated has %d ParamTags, but %v only takes %d parameters",
+			len(a.ParamTags), fxreflect.FuncName(a.Target), ft.NumIn())
+	}
+	fields := []reflect.StructField{{Name: _typeOfIn.Name(), Anonymous: true, Type: _typeOfIn}}
+	for i := 0; i < ft.NumIn(); i++ {
+		t := ft.In(i)
+		if dig.IsIn(t) {
+			return nil, fmt.Errorf("fx.Annotated ParamTags cannot be used with %v, which takes an fx.In object", fxreflect.FuncName(a.Target))
+		}
+		var tag string
+		if i < len(a.ParamTags) {
+			tag = a.ParamTags[i]
+		}
+		fields = append(fields, reflect.StructField{
+			Name: fmt.Sprintf("F%d", i),
+			Type: t,
+			Tag:  reflect.StructTag(tag),
+		})
+	}
+	return []reflect.Type{reflect.StructOf(fields)}, nil
+}
+
+// resultTypes returns the result types of the function generated for a,
+// whose Target has type ft, and the index of the error returned by Target,
+// or -1.
+func (a Annotated) resultTypes(ft reflect.Type) ([]reflect.Type, int, error) {
+	errIndex := -1
+	if n := ft.NumOut(); n > 0 && ft.Out(n-1) == _typeOfError {
+		errIndex = n - 1
+	}
+
+	if len(a.ResultTags) == 0 {
+		outs := make([]reflect.Type, ft.NumOut())
+		for i := range outs {
+			outs[i] = ft.Out(i)
+		}
+		return outs, errIndex, nil
+	}
+
+	values := ft.NumOut()
+	if errIndex >= 0 {
+		values--
+	}
+	if len(a.ResultTags) > values {
+		return nil, 0, fmt.Errorf("fx.Annotated has %d ResultTags, but %v only returns %d values",
+			len(a.ResultTags), fxreflect.FuncName(a.Target), values)
+	}
+	fields := []reflect.StructField{{Name: _typeOfOut.Name(), Anonymous: true, Type: _typeOfOut}}
+	for i := 0; i < values; i++ {
+		t := ft.Out(i)
+		if dig.IsOut(t) {
+			return nil, 0, fmt.Errorf("fx.Annotated ResultTags cannot be used with %v, which returns an fx.Out object", fxreflect.FuncName(a.Target))
+		}
+		var tag string
+		if i < len(a.ResultTags) {
+			tag = a.ResultTags[i]
+		}
+		fields = append(fields, reflect.StructField{
+			Name: fmt.Sprintf("F%d", i),
+			Type: t,
+			Tag:  reflect.StructTag(tag),
+		})
+	}
+
+	outs := []reflect.Type{reflect.StructOf(fields)}
+	if errIndex >= 0 {
+		outs = append(outs, _typeOfError)
+	}
+	return outs, errIndex, nil
+}
+
+// asTypes returns the interfaces listed in a.As, checking that the values
+// returned by a.Target implement them.
+func (a Annotated) asTypes() ([]reflect.Type, error) {
+	if len(a.As) == 0 {
+		return nil, nil
+	}
+	if len(a.Group) > 0 {
+		return nil, fmt.Errorf("fx.Annotated may not specify both As and group for %v", a)
+	}
+
+	ifaces := make([]reflect.Type, len(a.As))
+	for i, as := range a.As {
+		t := reflect.TypeOf(as)
+		if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Interface {
+			return nil, fmt.Errorf("fx.Annotated As must list pointers to interfaces, such as new(io.Reader): got %v", t)
+		}
+		ifaces[i] = t.Elem()
+	}
+
+	ft := reflect.TypeOf(a.Target)
+	if ft == nil || ft.Kind() != reflect.Func {
+		// Reported by dig.
+		return ifaces, nil
+	}
+	for i := 0; i < ft.NumOut(); i++ {
+		rt := ft.Out(i)
+		if rt == _typeOfError {
+			continue
+		}
+		if dig.IsOut(rt) {
+			return nil, fmt.Errorf("fx.Annotated As cannot be used with %v, which returns an fx.Out object", fxreflect.FuncName(a.Target))
+		}
+		for _, iface := range ifaces {
+			if !rt.Implements(iface) {
+				return nil, fmt.Errorf("fx.Annotated As: %v returned by %v does not implement %v",
+					rt, fxreflect.FuncName(a.Target), iface)
+			}
+		}
+	}
+	return ifaces, nil
 }
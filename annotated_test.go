@@ -21,9 +21,13 @@
 package fx_test
 
 import (
+	"bytes"
+	"errors"
+	"log"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/fx"
 	"go.uber.org/fx/fxtest"
 )
@@ -100,3 +104,230 @@ func TestAnnotatedWrongUsage(t *testing.T) {
 		assert.Contains(t, app.Err().Error(), "embeds a dig.In", "expected error when result types were annotated")
 	})
 }
+
+type userStore interface {
+	User(id int) string
+}
+
+type postgresStore struct{}
+
+func (*postgresStore) User(int) string { return "gopher" }
+
+func TestAnnotatedAs(t *testing.T) {
+	newStore := func() *postgresStore { return &postgresStore{} }
+
+	t.Run("Provided", func(t *testing.T) {
+		type in struct {
+			fx.In
+
+			Store   *postgresStore `name:"primary"`
+			AsStore userStore      `name:"primary"`
+			Unnamed userStore      `optional:"true"`
+		}
+		var got in
+		app := fxtest.New(t,
+			fx.Provide(fx.Annotated{
+				Name:   "primary",
+				Target: newStore,
+				As:     []interface{}{new(userStore)},
+			}),
+			fx.Populate(&got),
+		)
+		defer app.RequireStart().RequireStop()
+
+		assert.NotNil(t, got.Store)
+		assert.Equal(t, got.Store, got.AsStore, "expected the same value as the interface")
+		assert.Nil(t, got.Unnamed, "the name must apply to the interface too")
+	})
+
+	t.Run("Supplied", func(t *testing.T) {
+		var store userStore
+		app := fxtest.New(t,
+			fx.Supply(fx.Annotated{Target: &postgresStore{}, As: []interface{}{new(userStore)}}),
+			fx.Populate(&store),
+		)
+		defer app.RequireStart().RequireStop()
+		assert.Equal(t, "gopher", store.User(1))
+	})
+
+	t.Run("DoesNotImplement", func(t *testing.T) {
+		app := fx.New(fx.NopLogger, fx.Provide(fx.Annotated{
+			Target: func() *bytes.Buffer { return nil },
+			As:     []interface{}{new(userStore)},
+		}))
+		require.Error(t, app.Err())
+		assert.Contains(t, app.Err().Error(), "*bytes.Buffer returned by")
+		assert.Contains(t, app.Err().Error(), "does not implement fx_test.userStore")
+	})
+
+	t.Run("NotAnInterface", func(t *testing.T) {
+		app := fx.New(fx.NopLogger, fx.Provide(fx.Annotated{
+			Target: newStore,
+			As:     []interface{}{new(postgresStore)},
+		}))
+		require.Error(t, app.Err())
+		assert.Contains(t, app.Err().Error(), "must list pointers to interfaces")
+	})
+
+	t.Run("WithGroup", func(t *testing.T) {
+		app := fx.New(fx.NopLogger, fx.Provide(fx.Annotated{
+			Group:  "stores",
+			Target: newStore,
+			As:     []interface{}{new(userStore)},
+		}))
+		require.Error(t, app.Err())
+		assert.Contains(t, app.Err().Error(), "may not specify both As and group")
+	})
+}
+
+func TestAnnotatedTags(t *testing.T) {
+	type conn struct{ name string }
+	type logger struct{}
+	type service struct {
+		conn   *conn
+		logger *logger
+	}
+	newConns := func() (*conn, *conn, error) {
+		return &conn{name: "ro"}, &conn{name: "rw"}, nil
+	}
+
+	t.Run("ParamsAndResults", func(t *testing.T) {
+		var s *service
+		app := fxtest.New(t,
+			fx.Provide(
+				fx.Annotated{
+					Target:     newConns,
+					ResultTags: []string{`name:"ro"`, `name:"rw"`},
+				},
+				fx.Annotated{
+					Target: func(c *conn, l *logger) *service {
+						return &service{conn: c, logger: l}
+					},
+					ParamTags: []string{`name:"rw"`, `optional:"true"`},
+				},
+			),
+			fx.Populate(&s),
+		)
+		defer app.RequireStart().RequireStop()
+
+		assert.Equal(t, "rw", s.conn.name)
+		assert.Nil(t, s.logger)
+	})
+
+	t.Run("Groups", func(t *testing.T) {
+		type in struct {
+			fx.In
+
+			Conns []*conn `group:"conns"`
+		}
+		var got in
+		app := fxtest.New(t,
+			fx.Provide(
+				fx.Annotated{Target: newConns, ResultTags: []string{`group:"conns"`, `group:"conns"`}},
+			),
+			fx.Populate(&got),
+		)
+		defer app.RequireStart().RequireStop()
+		assert.Len(t, got.Conns, 2)
+	})
+
+	t.Run("Variadic", func(t *testing.T) {
+		var s *service
+		app := fxtest.New(t,
+			fx.Provide(
+				fx.Annotated{Name: "ro", Target: func() *conn { return &conn{name: "ro"} }},
+				fx.Annotated{
+					Target: func(c *conn, opts ...string) *service {
+						return &service{conn: c}
+					},
+					ParamTags: []string{`name:"ro"`, `optional:"true"`},
+				},
+			),
+			fx.Populate(&s),
+		)
+		defer app.RequireStart().RequireStop()
+		assert.Equal(t, "ro", s.conn.name)
+	})
+
+	t.Run("AutoLifecycle", func(t *testing.T) {
+		var events []string
+		spy := &bytes.Buffer{}
+		newServer := func(c *conn) *autoServer { return &autoServer{&events, c.name} }
+		app := fxtest.New(t,
+			fx.Logger(log.New(spy, "", 0)),
+			fx.Provide(
+				fx.Annotated{Name: "ro", Target: func() *conn { return &conn{name: "ro"} }},
+				fx.Annotated{
+					Target:        newServer,
+					ParamTags:     []string{`name:"ro"`},
+					AutoLifecycle: true,
+				},
+			),
+			fx.Invoke(func(*autoServer) {}),
+		)
+		app.RequireStart().RequireStop()
+
+		assert.Equal(t, []string{"start ro", "stop ro"}, events)
+		assert.Contains(t, spy.String(), "START\t\t"+funcPath(newServer)+"()")
+		assert.NotContains(t, spy.String(), "makeFuncStub")
+	})
+
+	t.Run("ResultError", func(t *testing.T) {
+		app := fx.New(fx.NopLogger,
+			fx.Provide(fx.Annotated{
+				Target: func() (*conn, error) {
+					return nil, errors.New("great sadness")
+				},
+				ResultTags: []string{`name:"ro"`},
+			}),
+			fx.Invoke(func(p struct {
+				fx.In
+
+				Conn *conn `name:"ro"`
+			}) {
+			}),
+		)
+		require.Error(t, app.Err())
+		assert.Contains(t, app.Err().Error(), "great sadness")
+	})
+
+	t.Run("WrongUsage", func(t *testing.T) {
+		tests := []struct {
+			desc    string
+			give    fx.Annotated
+			wantErr string
+		}{
+			{
+				desc:    "too many params",
+				give:    fx.Annotated{Target: func(*conn) *service { return nil }, ParamTags: []string{``, ``}},
+				wantErr: "has 2 ParamTags, but",
+			},
+			{
+				desc:    "too many results",
+				give:    fx.Annotated{Target: newConns, ResultTags: []string{``, ``, ``}},
+				wantErr: "has 3 ResultTags, but",
+			},
+			{
+				desc: "fx.In param",
+				give: fx.Annotated{
+					Target:    func(struct{ fx.In }) *service { return nil },
+					ParamTags: []string{`name:"ro"`},
+				},
+				wantErr: "which takes an fx.In object",
+			},
+			{
+				desc:    "with name",
+				give:    fx.Annotated{Name: "ro", Target: newConns, ResultTags: []string{`name:"rw"`}},
+				wantErr: "may not specify ResultTags along with Name",
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.desc, func(t *testing.T) {
+				app := fx.New(fx.NopLogger, fx.Provide(tt.give))
+				require.Error(t, app.Err())
+				assert.Contains(t, app.Err().Error(), tt.wantErr)
+			})
+		}
+	})
+}
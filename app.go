@@ -27,7 +27,6 @@ import (
 	"fmt"
 	"go.uber.org/zap"
 	"os"
-	"os/signal"
 	"reflect"
 	"strings"
 	"sync"
@@ -47,6 +46,11 @@ import (
 // options.
 const DefaultTimeout = 15 * time.Second
 
+// DefaultForceExitCode is the default exit code of an application using Run
+// that's forced to exit by a second shutdown signal. It can be configured with
+// the ForceExitCode option.
+const DefaultForceExitCode = 130
+
 // An Option configures an App using the functional options paradigm
 // popularized by Rob Pike. If you're unfamiliar with this style, see
 // https://commandcenter.blogspot.com/2014/01/self-referential-functions-and-design.html.
@@ -87,7 +91,9 @@ func (f optionFunc) apply(app *App) { f(app) }
 // Constructor functions should perform as little external interaction as
 // possible, and should avoid spawning goroutines. Things like server listen
 // loops, background timer loops, and background processing goroutines should
-// instead be managed using Lifecycle callbacks.
+// instead be managed using Lifecycle callbacks, or run with Go. With the
+// AutoLifecycle option, values with Start and Stop methods get their
+// Lifecycle callbacks appended automatically.
 func Provide(constructors ...interface{}) Option {
 	return provideOption(constructors)
 }
@@ -95,12 +101,31 @@ func Provide(constructors ...interface{}) Option {
 type provideOption []interface{}
 
 func (po provideOption) apply(app *App) {
-	app.provides = append(app.provides, po...)
+	var private bool
+	for _, c := range po {
+		if _, ok := c.(privateOption); ok {
+			private = true
+		}
+	}
+
+	for _, c := range po {
+		if _, ok := c.(privateOption); ok {
+			continue
+		}
+		if private {
+			app.addPrivate(c)
+		}
+		app.provides = append(app.provides, c)
+	}
 }
 
 func (po provideOption) String() string {
 	items := make([]string, len(po))
 	for i, c := range po {
+		if p, ok := c.(privateOption); ok {
+			items[i] = p.String()
+			continue
+		}
 		items[i] = fxreflect.FuncName(c)
 	}
 	return fmt.Sprintf("fx.Provide(%s)", strings.Join(items, ", "))
@@ -219,6 +244,14 @@ func StopTimeout(v time.Duration) Option {
 	})
 }
 
+// ForceExitCode changes the code with which an application using Run exits
+// when a second shutdown signal forces it to exit before it has stopped.
+func ForceExitCode(code int) Option {
+	return optionFunc(func(app *App) {
+		app.forceExitCode = code
+	})
+}
+
 // Printer is the interface required by Fx's logging backend. It's implemented
 // by most loggers, including the one bundled with the standard library.
 type Printer interface {
@@ -286,7 +319,8 @@ func (l nopLogger) Printf(string, ...interface{}) {
 // OnStart hooks are executed one at a time, in order, and must all complete
 // within a configurable deadline (by default, 15 seconds). For details on the
 // order in which OnStart hooks are executed, see the documentation for the
-// Start method.
+// Start method. Applications using the ParallelLifecycle option run
+// independent hooks concurrently instead.
 //
 // At this point, the application has successfully started up. If started via
 // Run, it will continue operating until it receives a shutdown signal from
@@ -294,28 +328,77 @@ func (l nopLogger) Printf(string, ...interface{}) {
 // Start, it will operate until the user calls Stop. On shutdown, OnStop hooks
 // execute one at a time, in reverse order, and must all complete within a
 // configurable deadline (again, 15 seconds by default).
+//
+// The State method reports where the application is in this lifecycle, and
+// StateChanges notifies callers as it moves along.
+//
+// Every application provides an AppContext, a context.Context that's canceled
+// as soon as the application begins shutting down: when it receives a
+// shutdown signal, when the Shutdowner or Stop is called, or when Start fails.
+// Constructors that spawn background work should tie it to this context
+// rather than to context.Background. The context carries the application's
+// name (see AppNameFromContext). Once canceled, it stays canceled, even if
+// the application is started again.
 type App struct {
-	err          error
-	container    *dig.Container
-	lifecycle    *lifecycleWrapper
-	provides     []interface{}
-	invokes      []interface{}
-	decorators   []interface{}
-	logger       lifecycle.Logger
-	startTimeout time.Duration
-	stopTimeout  time.Duration
-	errorHooks   []ErrorHandler
+	err           error
+	container     *dig.Container
+	lifecycle     *lifecycleWrapper
+	provides      []interface{}
+	invokes       []interface{}
+	decorators    []interface{}
+	logger        lifecycle.Logger
+	startTimeout  time.Duration
+	stopTimeout   time.Duration
+	errorHooks    []ErrorHandler
+	parallel      bool
+	deps          *depGraph
+	recoverPanics bool
+	autoLifecycle bool
+
+	stateMu   sync.Mutex
+	state     State
+	stateSubs []chan State
+	// Closed once the start that Start gave up on has rolled back.
+	startAbandoned chan struct{}
 
 	donesMu sync.RWMutex
 	dones   []chan os.Signal
+	waits   []chan ShutdownSignal
+
+	signals   []os.Signal
+	signalsMu sync.Mutex
+	sigc      chan os.Signal
+
+	reloadSignals []os.Signal
+	// Holds a token while lifecycle reloads run, including those that
+	// Reload gave up waiting for.
+	reloading chan struct{}
+
+	name      string
+	ctx       context.Context
+	cancelCtx context.CancelFunc
+
+	drainPeriod   time.Duration
+	forceExitCode int
+	interruptMu   sync.Mutex
+	interruptFunc func(ShutdownSignal)
+
+	shutdownMu  sync.Mutex
+	shutdownReq shutdownRequest
 
 	children []*App
+	replaces []*replacement
 	parent   *App
+
+	// For modules, the name of the module, and the values private to it.
+	module  string
+	private map[depKey]struct{}
 }
 
 // ErrorHook registers error handlers that implement error handling functions.
-// They are executed on invoke failures. Passing multiple ErrorHandlers appends
-// the new handlers to the application's existing list.
+// They are executed on invoke failures, on reload failures, and on panics
+// recovered by the RecoverFromPanics option. Passing multiple ErrorHandlers
+// appends the new handlers to the application's existing list.
 func ErrorHook(funcs ...ErrorHandler) Option {
 	return errorHookOption(funcs)
 }
@@ -347,20 +430,44 @@ func New(opts ...Option) *App {
 	lc := &lifecycleWrapper{lifecycle.New(logger)}
 
 	app := &App{
-		container:    dig.New(dig.DeferAcyclicVerification()),
-		lifecycle:    lc,
-		logger:       logger,
-		startTimeout: DefaultTimeout,
-		stopTimeout:  DefaultTimeout,
+		container:     dig.New(dig.DeferAcyclicVerification()),
+		lifecycle:     lc,
+		logger:        logger,
+		startTimeout:  DefaultTimeout,
+		stopTimeout:   DefaultTimeout,
+		deps:          newDepGraph(),
+		signals:       []os.Signal{syscall.SIGINT, syscall.SIGTERM},
+		reloadSignals: []os.Signal{syscall.SIGHUP},
+		reloading:     make(chan struct{}, 1),
+		forceExitCode: DefaultForceExitCode,
+		name:          defaultAppName(),
 	}
 
 	for _, opt := range opts {
 		opt.apply(app)
 	}
 
+	app.ctx, app.cancelCtx = context.WithCancel(
+		context.WithValue(context.Background(), appNameKey{}, app.name))
+
+	if app.parallel {
+		app.lifecycle.Parallelize(app.deps.dependsOn)
+	}
+	app.lifecycle.OnWorkerFailure(app.workerFailed)
+	app.lifecycle.WrapErrors()
+	if app.drainPeriod > 0 {
+		app.lifecycle.OnDrain(app.drain)
+	}
+	if app.recoverPanics {
+		app.lifecycle.RecoverFromPanics()
+	}
+
+	app.checkReplacements()
 	provideAll(app)
 	app.provide(func() Lifecycle { return app.lifecycle })
 	app.provide(app.shutdowner)
+	app.provide(app.reloader)
+	app.provide(app.context)
 	app.provide(app.dotGraph)
 
 	decorateAll(app)
@@ -387,12 +494,21 @@ func New(opts ...Option) *App {
 }
 
 func provideAll(app *App) {
+	for _, r := range app.replaces {
+		app.provideReplacement(r)
+	}
 	for _, p := range app.provides {
-		app.provide(p)
+		if !app.replaced(p) {
+			app.provide(p)
+		}
 	}
 
 	for _, ca := range app.children {
 		provideAll(ca)
+		if app.err == nil {
+			// Report failures inside modules too.
+			app.err = ca.err
+		}
 	}
 }
 
@@ -444,6 +560,23 @@ func VisualizeError(err error) (string, error) {
 // configured different timeouts with the StartTimeout or StopTimeout options.
 // It's designed to make typical applications simple to run.
 //
+// While the application is running, Run reloads it when it receives one of
+// its reload signals; see Reloader for details.
+//
+// If the application was shut down using the Shutdowner, Run honors the
+// ShutdownTimeout, ShutdownReason, and ExitCode options passed to Shutdown:
+// with a non-zero exit code, Run exits the process once the application has
+// stopped.
+//
+// If the application receives another OS shutdown signal while it's
+// stopping, after the drain period if any, Run gives up on the Lifecycle
+// hooks that are still running or have yet to run, logs them, and exits the
+// process with the code set by ForceExitCode. Calls to Shutdown don't force
+// an exit.
+//
+// If the application fails to start or to stop cleanly, Run logs the error
+// and exits the process with code 1. Use RunE to handle these errors instead.
+//
 // However, all of Run's functionality is implemented in terms of the exported
 // Start, Done, and Stop methods. Applications with more specialized needs
 // can use those methods directly instead of relying on Run.
@@ -451,6 +584,21 @@ func (app *App) Run() {
 	app.run(app.Done())
 }
 
+// RunE is like Run, but it returns instead of exiting the process, so that
+// deferred functions run and programs embedding the application can recover.
+// It returns the error that made starting or stopping the application fail,
+// and an *ExitError if the application stopped cleanly but should exit with
+// a non-zero code, as requested with the ExitCode option or forced by a second
+// shutdown signal. It returns nil once the application has stopped otherwise.
+//
+// Canceling ctx shuts the application down like a shutdown signal, and it
+// also bounds the start of the application. If the start times out, RunE
+// waits for the application to roll back, up to the StopTimeout, before
+// returning.
+func (app *App) RunE(ctx context.Context) error {
+	return app.runE(ctx, app.Done())
+}
+
 // Err returns any error encountered during New's initialization. See the
 // documentation of the New method for details, but typical errors include
 // missing constructors, circular dependencies, constructor errors, and
@@ -472,15 +620,50 @@ func (app *App) Err() error {
 // order, hooks are naturally registered in dependency order too.
 //
 // Start executes all OnStart hooks registered with the application's
-// Lifecycle, one at a time and in order. This ensures that each constructor's
-// start hooks aren't executed until all its dependencies' start hooks
-// complete. If any of the start hooks return an error, Start short-circuits,
-// calls Stop, and returns the inciting error.
+// Lifecycle, one at a time and in order, or concurrently where the
+// dependency graph allows with the ParallelLifecycle option. Either way, each
+// constructor's start hooks aren't executed until all its dependencies' start
+// hooks complete. If any of the start hooks return an error, Start
+// short-circuits, calls Stop, and returns the inciting error, wrapped in a
+// *HookError. OnPreStart and OnPostStart callbacks run before and after all
+// OnStart hooks; see the Hook documentation for details.
 //
 // Note that Start short-circuits immediately if the New constructor
 // encountered any errors in application initialization.
+//
+// If ctx expires while hooks are still running, Start logs those hooks along
+// with their stack traces and returns a *TimeoutError describing them. The
+// application stays in StateStarting until those hooks return, and then
+// rolls back as if they had failed, never moving to StateRunning. Stop may be
+// called meanwhile to wait for the rollback.
+//
+// Start may be called on a newly created application, or on one that was
+// stopped (or failed to start) to start it again: all OnStart hooks are
+// executed again. Otherwise, Start returns a *StateError.
 func (app *App) Start(ctx context.Context) error {
-	return withTimeout(ctx, app.start)
+	if app.err != nil {
+		// Some provides failed, short-circuit immediately.
+		return app.err
+	}
+	if err := app.transition("start", StateStarting, StateCreated, StateStopped); err != nil {
+		return err
+	}
+
+	app.shutdownMu.Lock()
+	app.shutdownReq = shutdownRequest{}
+	app.shutdownMu.Unlock()
+
+	if app.hasSignalChannels() {
+		// Resume relaying signals after a restart.
+		app.relaySignals()
+	}
+
+	done := make(chan struct{})
+	start := func(ctx context.Context) error {
+		defer close(done)
+		return app.start(ctx)
+	}
+	return app.withTimeout(ctx, start, func() bool { return app.abandonStart(done) })
 }
 
 // Stop gracefully stops the application. It executes any registered OnStop
@@ -489,22 +672,53 @@ func (app *App) Start(ctx context.Context) error {
 //
 // If the application didn't start cleanly, only hooks whose OnStart phase was
 // called are executed. However, all those hooks are executed, even if some
-// fail.
+// fail, and Stop returns their errors combined, each wrapped in a *HookError.
+// OnPreStop and OnPostStop callbacks run before and after all OnStop hooks.
+// Like Start, Stop returns a *TimeoutError if ctx expires while hooks are
+// still running.
+//
+// With the DrainPeriod option, Stop waits for the drain period between the
+// OnPreStop callbacks and the OnStop hooks.
+//
+// Stop may only be called on a running application, or on one whose Start
+// timed out, in which case it waits for the application to roll back.
+// Otherwise, it returns a *StateError.
 func (app *App) Stop(ctx context.Context) error {
-	return withTimeout(ctx, app.lifecycle.Stop)
+	if done := app.abandonedStart(); done != nil {
+		// Start timed out, and the application rolls back once the hooks
+		// Start gave up on return. Wait for that instead.
+		return app.withTimeout(ctx, func(ctx context.Context) error {
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}, nil)
+	}
+	if err := app.transition("stop", StateStopping, StateRunning); err != nil {
+		return err
+	}
+	app.cancelContext()
+	return app.withTimeout(ctx, app.stop, nil)
 }
 
 // Done returns a channel of signals to block on after starting the
 // application. Applications listen for the SIGINT and SIGTERM signals; during
 // development, users can send the application SIGTERM by pressing Ctrl-C in
-// the same terminal as the running process.
+// the same terminal as the running process. The ShutdownSignals and
+// DisableSignalHandling options change which signals are listened for.
+//
+// Applications stop listening for signals once stopped, and listen again
+// when restarted.
 //
 // Alternatively, a signal can be broadcast to all done channels manually by
 // using the Shutdown functionality (see the Shutdowner documentation for details).
+// To tell apart the two, use Wait instead.
 func (app *App) Done() <-chan os.Signal {
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
+	app.relaySignals()
 
+	c := make(chan os.Signal, 1)
 	app.donesMu.Lock()
 	app.dones = append(app.dones, c)
 	app.donesMu.Unlock()
@@ -525,6 +739,14 @@ func (app *App) StopTimeout() time.Duration {
 	return app.stopTimeout
 }
 
+// workerFailed shuts down the application when a function registered with Go
+// fails, giving the function's error as the reason.
+func (app *App) workerFailed(fn string, reason error) {
+	if err := app.shutdown(fn, ShutdownReason(reason)); err != nil {
+		app.logger.Printf("ERROR\t\tFailed to shut down after worker failure: %v", err)
+	}
+}
+
 func (app *App) dotGraph() (DotGraph, error) {
 	var b bytes.Buffer
 	err := dig.Visualize(app.container, &b)
@@ -535,6 +757,10 @@ func (app *App) provide(constructor interface{}) {
 	if app.err != nil {
 		return
 	}
+	if s, ok := constructor.(supplied); ok {
+		app.supply(s)
+		return
+	}
 	app.logger.PrintProvide(constructor)
 
 	if _, ok := constructor.(Option); ok {
@@ -543,21 +769,7 @@ func (app *App) provide(constructor interface{}) {
 	}
 
 	if a, ok := constructor.(Annotated); ok {
-		var opts []dig.ProvideOption
-		switch {
-		case len(a.Group) > 0 && len(a.Name) > 0:
-			app.err = fmt.Errorf("fx.Annotate may not specify both name and group for %v", constructor)
-			return
-		case len(a.Name) > 0:
-			opts = append(opts, dig.Name(a.Name))
-		case len(a.Group) > 0:
-			opts = append(opts, dig.Group(a.Group))
-
-		}
-
-		if err := app.container.Provide(a.Target, opts...); err != nil {
-			app.err = err
-		}
+		app.provideAnnotated(a, funcPath(a.Target))
 		return
 	}
 
@@ -574,9 +786,63 @@ func (app *App) provide(constructor interface{}) {
 		}
 	}
 
-	if err := app.container.Provide(constructor); err != nil {
+	ctor := constructor
+	if app.root().autoLifecycle {
+		ctor = app.withLifecycleHooks(ctor, funcPath(constructor))
+	}
+	if err := app.checkPrivate(constructor); err != nil {
 		app.err = err
+		return
 	}
+	if err := app.container.Provide(ctor); err != nil {
+		app.err = err
+		return
+	}
+	app.deps.addProvide(constructor, "", "")
+}
+
+// provideAnnotated provides an Annotated constructor. Its Lifecycle hooks and
+// dependencies are attributed to caller: the target itself, rather than the
+// function built for its ParamTags and ResultTags, or the caller of Supply.
+func (app *App) provideAnnotated(a Annotated, caller string) {
+	var opts []dig.ProvideOption
+	switch {
+	case len(a.Group) > 0 && len(a.Name) > 0:
+		app.err = fmt.Errorf("fx.Annotate may not specify both name and group for %v", a)
+		return
+	case len(a.Name) > 0:
+		opts = append(opts, dig.Name(a.Name))
+	case len(a.Group) > 0:
+		opts = append(opts, dig.Group(a.Group))
+
+	}
+	as, err := a.asTypes()
+	if err != nil {
+		app.err = err
+		return
+	}
+	if len(as) > 0 {
+		opts = append(opts, dig.As(a.As...))
+	}
+
+	ctor, err := a.build()
+	if err != nil {
+		app.err = err
+		return
+	}
+	target := ctor
+	if a.AutoLifecycle || app.root().autoLifecycle {
+		target = app.withLifecycleHooks(target, caller)
+	}
+	if err := app.checkPrivateParams(fxreflect.FuncName(a.Target), reflect.TypeOf(ctor)); err != nil {
+		app.err = err
+		return
+	}
+	if err := app.container.Provide(target, opts...); err != nil {
+		app.err = err
+		return
+	}
+	app.deps.addProvideFunc(caller, reflect.TypeOf(ctor), a.Name, a.Group, as...)
 }
 
 func (app *App) decorate(constructor interface{}) {
@@ -603,7 +869,16 @@ func (app *App) decorate(constructor interface{}) {
 
 		}
 
-		if err := app.container.Decorate(a.Target, opts...); err != nil {
+		decorator, err := a.build()
+		if err != nil {
+			app.err = err
+			return
+		}
+		if err := app.checkPrivateParams(fxreflect.FuncName(a.Target), reflect.TypeOf(decorator)); err != nil {
+			app.err = err
+			return
+		}
+		if err := app.container.Decorate(decorator, opts...); err != nil {
 			app.err = err
 		}
 		return
@@ -622,6 +897,10 @@ func (app *App) decorate(constructor interface{}) {
 		}
 	}
 
+	if err := app.checkPrivate(constructor); err != nil {
+		app.err = err
+		return
+	}
 	if err := app.container.Decorate(constructor); err != nil {
 		app.err = err
 	}
@@ -634,13 +913,18 @@ func (app *App) executeInvokes() error {
 	var err error
 
 	for _, fn := range app.invokes {
+		scope := app
+		if mi, ok := fn.(moduleInvoke); ok {
+			fn, scope = mi.fn, mi.module
+		}
 		fname := fxreflect.FuncName(fn)
 		app.logger.Printf("INVOKE\t\t%s", fname)
 
 		if _, ok := fn.(Option); ok {
 			err = fmt.Errorf("fx.Option should be passed to fx.New directly, not to fx.Invoke: fx.Invoke received %v", fn)
-		} else {
-			err = app.container.Invoke(fn)
+		} else if err = scope.checkPrivate(fn); err == nil {
+			app.deps.addInvoke(fn)
+			err = app.invoke(fn)
 		}
 
 		if err != nil {
@@ -653,54 +937,164 @@ func (app *App) executeInvokes() error {
 }
 
 func (app *App) run(done <-chan os.Signal) {
-	startCtx, cancel := context.WithTimeout(context.Background(), app.StartTimeout())
+	if err := app.runE(context.Background(), done); err != nil {
+		var exitErr *ExitError
+		if errors.As(err, &exitErr) {
+			_exit(exitErr.Code)
+			return
+		}
+		_exit(1)
+	}
+}
+
+func (app *App) runE(ctx context.Context, done <-chan os.Signal) error {
+	startCtx, cancel := context.WithTimeout(ctx, app.StartTimeout())
 	defer cancel()
 
 	if err := app.Start(startCtx); err != nil {
-		app.logger.Fatalf("ERROR\t\tFailed to start: %v", err)
+		app.logger.Printf("ERROR\t\tFailed to start: %v", err)
+		var stateErr *StateError
+		if !errors.As(err, &stateErr) {
+			// Done registered the shutdown signals, but the application
+			// won't run. A StateError leaves them to the call to Start or
+			// Stop in progress.
+			app.stopRelayingSignals()
+		}
+		if app.abandonedStart() != nil {
+			// Start timed out. Don't leave the hooks it gave up on behind.
+			stopCtx, cancel := context.WithTimeout(context.Background(), app.StopTimeout())
+			defer cancel()
+			if stopErr := app.Stop(stopCtx); stopErr != nil {
+				app.logger.Printf("ERROR\t\tFailed to roll back: %v", stopErr)
+				err = multierr.Append(err, stopErr)
+			}
+		}
+		return fmt.Errorf("failed to start: %w", err)
 	}
 
-	app.logger.PrintSignal(<-done)
+	// Once the application is shutting down, another OS signal forces it to
+	// exit. Watch for it right away, so that it isn't lost while the first
+	// signal is handled.
+	var (
+		forced = make(chan struct{})
+		force  sync.Once
+
+		shuttingDownMu sync.Mutex
+		shuttingDown   bool
+	)
+	startShutdown := func() (first bool) {
+		shuttingDownMu.Lock()
+		defer shuttingDownMu.Unlock()
+		first, shuttingDown = !shuttingDown, true
+		return first
+	}
+	restore := app.onInterrupt(func(s ShutdownSignal) {
+		if startShutdown() || s.Signal == nil {
+			// Only OS signals force an exit: the application may call
+			// Shutdown itself while stopping.
+			return
+		}
+		force.Do(func() {
+			app.logForcedExit()
+			close(forced)
+		})
+	})
+	defer restore()
+
+	stopReloading := app.handleReloadSignals()
+	select {
+	case sig := <-done:
+		app.logger.PrintSignal(sig)
+	case <-ctx.Done():
+		app.logger.Printf("SHUTDOWN\t%v", ctx.Err())
+	}
+	stopReloading()
+	startShutdown()
 
-	stopCtx, cancel := context.WithTimeout(context.Background(), app.StopTimeout())
+	req := app.shutdownRequest()
+	if req.reason != nil {
+		app.logger.Printf("SHUTDOWN\t%v", req.reason)
+	}
+	stopTimeout := app.StopTimeout()
+	if req.timeout > 0 {
+		stopTimeout = req.timeout
+	}
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), stopTimeout)
 	defer cancel()
 
-	if err := app.Stop(stopCtx); err != nil {
-		app.logger.Fatalf("ERROR\t\tFailed to stop cleanly: %v", err)
+	stopped := make(chan error, 1)
+	go func() { stopped <- app.Stop(stopCtx) }()
+
+	select {
+	case err := <-stopped:
+		if err != nil {
+			app.logger.Printf("ERROR\t\tFailed to stop cleanly: %v", err)
+			return fmt.Errorf("failed to stop cleanly: %w", err)
+		}
+	case <-forced:
+		// Leave the remaining hooks behind; canceling stopCtx tells those
+		// still running to give up.
+		return &ExitError{Code: app.forceExitCode, Reason: req.reason, Forced: true}
+	}
+
+	if req.exitCode != 0 {
+		return &ExitError{Code: req.exitCode, Reason: req.reason}
 	}
+	return nil
 }
 
-func (app *App) start(ctx context.Context) error {
-	if app.err != nil {
-		// Some provides failed, short-circuit immediately.
-		return app.err
+// logForcedExit logs the Lifecycle hooks that are abandoned or skipped when
+// a second shutdown signal forces the application to exit.
+func (app *App) logForcedExit() {
+	app.logger.Printf("SHUTDOWN\tForced to exit by a second shutdown signal")
+	for _, h := range app.lifecycle.StuckHooks() {
+		app.logger.Printf("ERROR\t\tAbandoned %v", h)
+	}
+	for _, h := range app.lifecycle.PendingStops() {
+		app.logger.Printf("ERROR\t\tSkipped %v", h)
 	}
+}
 
+func (app *App) start(ctx context.Context) error {
 	// Attempt to start cleanly.
-	if err := app.lifecycle.Start(ctx); err != nil {
-		// Start failed, roll back.
-		app.logger.Printf("ERROR\t\tStart failed, rolling back: %v", err)
-		if stopErr := app.lifecycle.Stop(ctx); stopErr != nil {
-			app.logger.Printf("ERROR\t\tCouldn't rollback cleanly: %v", stopErr)
-			return multierr.Append(err, stopErr)
-		}
-		return err
+	err := app.lifecycle.Start(ctx)
+	if err == nil && app.finishStart() {
+		app.logger.Printf("RUNNING\t\tstart took %v", app.lifecycle.StartReport().Duration)
+		return nil
 	}
 
-	app.logger.Printf("RUNNING")
-	return nil
+	// Start failed or gave up waiting for the hooks, roll back.
+	defer app.rolledBack()
+	defer app.stopRelayingSignals()
+	app.cancelContext()
+
+	if err == nil {
+		err = ctx.Err()
+	}
+	app.logger.Printf("ERROR\t\tStart failed, rolling back: %v", err)
+	if ctx.Err() != nil {
+		// Don't hand the OnStop hooks an expired context.
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.Background(), app.StopTimeout())
+		defer cancel()
+	}
+	if stopErr := app.lifecycle.Stop(ctx); stopErr != nil {
+		app.logger.Printf("ERROR\t\tCouldn't rollback cleanly: %v", stopErr)
+		err = multierr.Append(err, stopErr)
+	}
+	app.handlePanic(err)
+	return err
 }
 
-func withTimeout(ctx context.Context, f func(context.Context) error) error {
-	c := make(chan error, 1)
-	go func() { c <- f(ctx) }()
+func (app *App) stop(ctx context.Context) error {
+	defer app.setState(StateStopped)
+	defer app.stopRelayingSignals()
 
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	case err := <-c:
-		return err
-	}
+	err := app.lifecycle.Stop(ctx)
+	app.logger.Printf("STOPPED\t\tstop took %v", app.lifecycle.StopReport().Duration)
+	app.handlePanic(err)
+	return err
 }
 
 type decorateOption []interface{}
@@ -713,6 +1107,9 @@ func Decorate(funcs ...interface{}) Option {
 	return decorateOption(funcs)
 }
 
+// Module groups options under a named scope with its own child container.
+// Values provided inside a module are available to the whole application,
+// unless they're provided with Private.
 func Module(name string, opts ...Option) Option {
 	return moduleOption{
 		name:    name,
@@ -720,6 +1117,14 @@ func Module(name string, opts ...Option) Option {
 	}
 }
 
+// root returns the top-level application of a module.
+func (app *App) root() *App {
+	for app.parent != nil {
+		app = app.parent
+	}
+	return app
+}
+
 type moduleOption struct {
 	name    string
 	options []Option
@@ -731,12 +1136,23 @@ func (m moduleOption) apply(a *App) {
 		parent:    a,
 		container: cc,
 		logger:    a.logger,
+		deps:      a.deps,
+		module:    m.name,
 	}
 	a.children = append(a.children, ca)
 
 	for _, opt := range m.options {
-		switch opt.(type) {
-		case invokeOption, errorHookOption, optionFunc:
+		switch o := opt.(type) {
+		case invokeOption:
+			// Only the top-level application runs invokes. Remember the
+			// module of each function, which may use the module's private
+			// values.
+			fns := make(invokeOption, len(o))
+			for i, fn := range o {
+				fns[i] = moduleInvoke{fn: fn, module: ca}
+			}
+			fns.apply(a.root())
+		case errorHookOption, optionFunc:
 			opt.apply(a)
 		default:
 			opt.apply(ca)
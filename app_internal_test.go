@@ -21,10 +21,20 @@
 package fx
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
 	"os"
+	"os/signal"
 	"sync"
 	"syscall"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAppRun(t *testing.T) {
@@ -41,3 +51,350 @@ func TestAppRun(t *testing.T) {
 	done <- syscall.SIGINT
 	wg.Wait()
 }
+
+func TestAppRunShutdownOptions(t *testing.T) {
+	prev := _exit
+	defer func() { _exit = prev }()
+	var exitCode int
+	_exit = func(code int) { exitCode = code }
+
+	var (
+		s        Shutdowner
+		deadline time.Time
+	)
+	spy := &bytes.Buffer{}
+	app := New(
+		Logger(log.New(spy, "", 0)),
+		StopTimeout(time.Hour),
+		Populate(&s),
+		Invoke(func(lc Lifecycle) {
+			lc.Append(Hook{OnStop: func(ctx context.Context) error {
+				deadline, _ = ctx.Deadline()
+				return nil
+			}})
+		}),
+	)
+
+	done := app.Done()
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		app.run(done)
+	}()
+
+	waitForState(t, app, StateRunning)
+	require.NoError(t, s.Shutdown(
+		ExitCode(3),
+		ShutdownReason(errors.New("lost connection to the database")),
+		ShutdownTimeout(time.Minute),
+	))
+	<-finished
+
+	assert.Equal(t, 3, exitCode)
+	assert.Contains(t, spy.String(), "SHUTDOWN\tlost connection to the database")
+	assert.True(t, time.Until(deadline) <= time.Minute, "expected the shutdown timeout to apply, got deadline %v", deadline)
+}
+
+func TestAppRunForceExit(t *testing.T) {
+	prev := _exit
+	defer func() { _exit = prev }()
+	exitCode := -1
+	_exit = func(code int) { exitCode = code }
+
+	var (
+		s        Shutdowner
+		stopping = make(chan struct{})
+		release  = make(chan struct{})
+	)
+	spy := &syncPrinter{}
+	app := New(
+		Logger(spy),
+		ForceExitCode(42),
+		Populate(&s),
+		Invoke(func(lc Lifecycle) {
+			lc.Append(Hook{OnStop: func(context.Context) error { return nil }})
+			lc.Append(Hook{OnStop: func(context.Context) error {
+				close(stopping)
+				<-release
+				return nil
+			}})
+		}),
+	)
+
+	done := app.Done()
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		app.run(done)
+	}()
+
+	waitForState(t, app, StateRunning)
+	require.NoError(t, s.Shutdown())
+	<-stopping
+	assert.Equal(t, -1, exitCode, "the first signal must not force an exit")
+
+	require.NoError(t, s.Shutdown())
+	assert.Equal(t, -1, exitCode, "Shutdown must not force an exit")
+
+	app.broadcastSignal(ShutdownSignal{Signal: syscall.SIGINT})
+	<-finished
+	assert.Equal(t, 42, exitCode)
+	assert.Contains(t, spy.String(), "SHUTDOWN\tForced to exit by a second shutdown signal")
+	assert.Regexp(t, "ERROR\t\tAbandoned OnStop hook added by .*TestAppRunForceExit.* still running", spy.String())
+	assert.Regexp(t, "ERROR\t\tSkipped OnStop hook added by .*TestAppRunForceExit", spy.String())
+	close(release)
+}
+
+func TestAppRunForceExitBeforeStop(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	app := New(
+		NopLogger,
+		ForceExitCode(42),
+		Invoke(func(lc Lifecycle) {
+			lc.Append(Hook{OnStop: func(context.Context) error {
+				<-release
+				return nil
+			}})
+		}),
+	)
+
+	done := make(chan os.Signal)
+	errc := make(chan error, 1)
+	go func() { errc <- app.runE(context.Background(), done) }()
+	waitForState(t, app, StateRunning)
+
+	// Both signals arrive before runE reads the first one from done.
+	app.broadcastSignal(ShutdownSignal{Signal: syscall.SIGINT})
+	app.broadcastSignal(ShutdownSignal{Signal: syscall.SIGINT})
+	done <- syscall.SIGINT
+
+	var exitErr *ExitError
+	require.True(t, errors.As(<-errc, &exitErr), "expected an ExitError")
+	assert.True(t, exitErr.Forced, "the second signal must force an exit")
+	assert.Equal(t, 42, exitErr.Code)
+}
+
+func TestAppRunShutdownWhileStopping(t *testing.T) {
+	var (
+		s       Shutdowner
+		stopped []string
+	)
+	app := New(
+		NopLogger,
+		Populate(&s),
+		Invoke(func(lc Lifecycle) {
+			lc.Append(Hook{OnStop: func(context.Context) error {
+				stopped = append(stopped, "first")
+				return nil
+			}})
+			lc.Append(Hook{OnStop: func(context.Context) error {
+				stopped = append(stopped, "second")
+				return s.Shutdown()
+			}})
+		}),
+	)
+
+	done := app.Done()
+	errc := make(chan error, 1)
+	go func() { errc <- app.runE(context.Background(), done) }()
+
+	waitForState(t, app, StateRunning)
+	require.NoError(t, s.Shutdown())
+	require.NoError(t, <-errc, "Shutdown from an OnStop hook must not force an exit")
+	assert.Equal(t, []string{"second", "first"}, stopped)
+}
+
+func TestShutdownOptionsFirstWins(t *testing.T) {
+	var s Shutdowner
+	app := New(NopLogger, Populate(&s))
+	done := app.Done()
+	require.NoError(t, app.Start(context.Background()))
+
+	reason := errors.New("great sadness")
+	require.NoError(t, s.Shutdown(ShutdownReason(reason)))
+	<-done
+	require.NoError(t, s.Shutdown(ExitCode(2), ShutdownReason(errors.New("other"))))
+	<-done
+	assert.Equal(t, shutdownRequest{exitCode: 2, reason: reason}, app.shutdownRequest())
+
+	require.NoError(t, app.Stop(context.Background()))
+	require.NoError(t, app.Start(context.Background()))
+	assert.Equal(t, shutdownRequest{}, app.shutdownRequest(), "Start must reset the shutdown request")
+	require.NoError(t, app.Stop(context.Background()))
+}
+
+// syncPrinter records log output, allowing concurrent writes and reads.
+type syncPrinter struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (p *syncPrinter) Printf(format string, args ...interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(&p.buf, format+"\n", args...)
+}
+
+func (p *syncPrinter) String() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.buf.String()
+}
+
+func waitForState(t *testing.T, app *App, want State) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); app.State() != want; {
+		if time.Now().After(deadline) {
+			t.Fatalf("application didn't reach state %v, got %v", want, app.State())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAppSignalHandling(t *testing.T) {
+	relaying := func(app *App) bool {
+		app.signalsMu.Lock()
+		defer app.signalsMu.Unlock()
+		return app.sigc != nil
+	}
+
+	t.Run("StopsOnStop", func(t *testing.T) {
+		app := New(NopLogger)
+		app.Done()
+		assert.True(t, relaying(app))
+
+		require.NoError(t, app.Start(context.Background()))
+		require.NoError(t, app.Stop(context.Background()))
+		assert.False(t, relaying(app), "signals must be unregistered once stopped")
+
+		require.NoError(t, app.Start(context.Background()))
+		assert.True(t, relaying(app), "signals must be registered again on restart")
+		require.NoError(t, app.Stop(context.Background()))
+	})
+
+	t.Run("StopsOnFailedStart", func(t *testing.T) {
+		app := New(NopLogger, Invoke(func(lc Lifecycle) {
+			lc.Append(Hook{OnStart: func(context.Context) error {
+				return errors.New("great sadness")
+			}})
+		}))
+		app.Wait()
+
+		require.Error(t, app.Start(context.Background()))
+		assert.False(t, relaying(app))
+	})
+
+	t.Run("StopsOnFailedRunE", func(t *testing.T) {
+		app := New(NopLogger, Error(errors.New("great sadness")))
+		require.Error(t, app.RunE(context.Background()))
+		assert.False(t, relaying(app), "signals must be unregistered if the app can't start")
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		app := New(NopLogger, DisableSignalHandling())
+		app.Done()
+		assert.False(t, relaying(app))
+	})
+
+	t.Run("CustomSignals", func(t *testing.T) {
+		app := New(NopLogger, ShutdownSignals(syscall.SIGHUP))
+		wait := app.Wait()
+		require.NoError(t, app.Start(context.Background()))
+		defer app.Stop(context.Background())
+
+		p, err := os.FindProcess(os.Getpid())
+		require.NoError(t, err)
+		require.NoError(t, p.Signal(syscall.SIGHUP))
+
+		select {
+		case sig := <-wait:
+			assert.Equal(t, syscall.SIGHUP, sig.Signal)
+		case <-time.After(time.Second):
+			t.Fatal("wait channel did not receive signal")
+		}
+	})
+}
+
+func TestActiveReloadSignals(t *testing.T) {
+	onReload := Invoke(func(lc Lifecycle) {
+		lc.Append(Hook{OnReload: func(context.Context) error { return nil }})
+	})
+
+	tests := []struct {
+		desc string
+		opts []Option
+		want []os.Signal
+	}{
+		{"Default", []Option{onReload}, []os.Signal{syscall.SIGHUP}},
+		{"WithoutOnReload", nil, nil},
+		{
+			"OverlappingShutdownSignals",
+			[]Option{onReload, ShutdownSignals(syscall.SIGHUP, syscall.SIGTERM)},
+			nil,
+		},
+		{
+			"CustomSignals",
+			[]Option{onReload, ReloadSignals(syscall.SIGHUP, syscall.SIGUSR1)},
+			[]os.Signal{syscall.SIGHUP, syscall.SIGUSR1},
+		},
+		{"Disabled", []Option{onReload, DisableSignalHandling()}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			app := New(append(tt.opts, NopLogger)...)
+			assert.Empty(t, app.activeReloadSignals(), "must not listen before Start")
+
+			require.NoError(t, app.Start(context.Background()))
+			defer app.Stop(context.Background())
+			assert.Equal(t, tt.want, app.activeReloadSignals())
+		})
+	}
+}
+
+func TestAppRunReloadSignal(t *testing.T) {
+	// Keep SIGHUP from terminating the test binary before Run listens for it.
+	guard := make(chan os.Signal, 1)
+	signal.Notify(guard, syscall.SIGHUP)
+	defer signal.Stop(guard)
+
+	reloaded := make(chan struct{}, 1)
+	app := New(NopLogger, Invoke(func(lc Lifecycle) {
+		lc.Append(Hook{OnReload: func(context.Context) error {
+			select {
+			case reloaded <- struct{}{}:
+			default:
+			}
+			return nil
+		}})
+	}))
+
+	done := make(chan os.Signal)
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		app.run(done)
+	}()
+	waitForState(t, app, StateRunning)
+
+	p, err := os.FindProcess(os.Getpid())
+	require.NoError(t, err)
+
+	tick := time.NewTicker(10 * time.Millisecond)
+	defer tick.Stop()
+	timeout := time.After(time.Second)
+	for waiting := true; waiting; {
+		select {
+		case <-reloaded:
+			waiting = false
+		case <-tick.C:
+			require.NoError(t, p.Signal(syscall.SIGHUP))
+		case <-timeout:
+			t.Fatal("application didn't reload on SIGHUP")
+		}
+	}
+
+	done <- syscall.SIGTERM
+	<-finished
+}
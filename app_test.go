@@ -25,6 +25,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
+	"syscall"
 	"testing"
 	"time"
 
@@ -33,6 +36,7 @@ import (
 
 	. "go.uber.org/fx"
 	"go.uber.org/fx/fxtest"
+	"go.uber.org/fx/internal/fxreflect"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -432,6 +436,71 @@ func TestAppStart(t *testing.T) {
 		assert.Contains(t, err.Error(), "context deadline exceeded")
 	})
 
+	t.Run("TimeoutReportsStuckHooks", func(t *testing.T) {
+		block := make(chan struct{})
+		defer close(block)
+
+		spy := printerSpy{&bytes.Buffer{}}
+		app := fxtest.New(t,
+			Logger(spy),
+			Invoke(func(lc Lifecycle) {
+				lc.Append(Hook{OnStart: func(context.Context) error {
+					<-block
+					return nil
+				}})
+			}),
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		err := app.Start(ctx)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded), "expected a deadline error, got %v", err)
+
+		var te *TimeoutError
+		require.True(t, errors.As(err, &te), "expected a TimeoutError, got %v", err)
+		require.Len(t, te.Hooks, 1)
+		hook := te.Hooks[0]
+		assert.Equal(t, "OnStart", hook.Phase)
+		assert.Contains(t, hook.Caller, "TestAppStart")
+		assert.True(t, hook.Running >= 10*time.Millisecond, "hook ran for %v", hook.Running)
+		assert.Contains(t, hook.Stack, "TestAppStart")
+		assert.Contains(t, err.Error(), "OnStart hook added by go.uber.org/fx_test.TestAppStart.func")
+		assert.Contains(t, spy.String(), hook.Stack)
+	})
+
+	t.Run("HookTimeout", func(t *testing.T) {
+		type A struct{}
+		var stopped bool
+		newA := func(lc Lifecycle) *A {
+			lc.Append(Hook{
+				OnStop: func(context.Context) error {
+					stopped = true
+					return nil
+				},
+			})
+			lc.Append(Hook{
+				OnStart: func(ctx context.Context) error {
+					<-ctx.Done()
+					return ctx.Err()
+				},
+				OnStartTimeout: time.Millisecond,
+			})
+			return &A{}
+		}
+		app := fxtest.New(t,
+			Provide(newA),
+			Invoke(func(*A) {}),
+		)
+
+		err := app.Start(context.Background())
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded), "expected a deadline error, got %v", err)
+		assert.Contains(t, err.Error(), "OnStart hook added by go.uber.org/fx_test.TestAppStart.func")
+		assert.True(t, stopped, "expected the started hook to be rolled back")
+	})
+
 	t.Run("StartError", func(t *testing.T) {
 		failStart := func(lc Lifecycle) struct{} {
 			lc.Append(Hook{OnStart: func(context.Context) error {
@@ -468,7 +537,22 @@ func TestAppStart(t *testing.T) {
 		)
 		err := app.Start(context.Background())
 		require.Error(t, err)
-		assert.Equal(t, []error{errStart2, errStop1}, multierr.Errors(err))
+
+		errs := multierr.Errors(err)
+		require.Len(t, errs, 2)
+
+		var startErr, stopErr *HookError
+		require.True(t, errors.As(errs[0], &startErr), "expected a HookError, got %v", errs[0])
+		assert.Equal(t, HookStart, startErr.Phase)
+		assert.Equal(t, "OnStart", startErr.Callback)
+		assert.Contains(t, startErr.Caller, "TestAppStart")
+		assert.Equal(t, errStart2, startErr.Err)
+
+		require.True(t, errors.As(errs[1], &stopErr), "expected a HookError, got %v", errs[1])
+		assert.Equal(t, HookRollback, stopErr.Phase)
+		assert.Equal(t, "OnStop", stopErr.Callback)
+		assert.Equal(t, errStop1, stopErr.Err)
+		assert.Contains(t, stopErr.Error(), "failed during rollback: OnStop fail 1")
 	})
 
 	t.Run("InvokeNonFunction", func(t *testing.T) {
@@ -578,6 +662,932 @@ func TestAppStop(t *testing.T) {
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "OnStop fail")
 	})
+
+	t.Run("DrainPeriod", func(t *testing.T) {
+		var events []string
+		app := fxtest.New(t,
+			DrainPeriod(20*time.Millisecond),
+			Invoke(func(lc Lifecycle) {
+				lc.Append(Hook{
+					OnPreStop: func(context.Context) error {
+						events = append(events, "prestop")
+						return nil
+					},
+					OnStop: func(context.Context) error {
+						events = append(events, "stop")
+						return nil
+					},
+				})
+			}),
+		)
+		app.RequireStart()
+
+		began := time.Now()
+		require.NoError(t, app.Stop(context.Background()))
+		assert.True(t, time.Since(began) >= 20*time.Millisecond, "stop took %v", time.Since(began))
+		assert.Equal(t, []string{"prestop", "stop"}, events)
+	})
+
+	t.Run("DrainPeriodCutShort", func(t *testing.T) {
+		var shutdowner Shutdowner
+		drained := make(chan struct{})
+		app := fxtest.New(t,
+			DrainPeriod(time.Minute),
+			Populate(&shutdowner),
+			Invoke(func(lc Lifecycle) {
+				lc.Append(Hook{OnPreStop: func(context.Context) error {
+					close(drained)
+					return nil
+				}})
+			}),
+		)
+		app.RequireStart()
+		require.NoError(t, shutdowner.Shutdown(), "signal that starts the shutdown")
+
+		go func() {
+			<-drained
+			assert.NoError(t, shutdowner.Shutdown(), "second signal")
+		}()
+
+		began := time.Now()
+		require.NoError(t, app.Stop(context.Background()))
+		assert.True(t, time.Since(began) < time.Minute, "the second signal must cut the drain period short")
+	})
+}
+
+func TestAppState(t *testing.T) {
+	t.Run("Transitions", func(t *testing.T) {
+		app := fxtest.New(t)
+		changes := app.StateChanges()
+		assert.Equal(t, StateCreated, app.State())
+
+		app.RequireStart()
+		assert.Equal(t, StateRunning, app.State())
+		app.RequireStop()
+		assert.Equal(t, StateStopped, app.State())
+
+		for _, want := range []State{StateStarting, StateRunning, StateStopping, StateStopped} {
+			assert.Equal(t, want, <-changes)
+		}
+	})
+
+	t.Run("StartTwice", func(t *testing.T) {
+		app := fxtest.New(t)
+		defer app.RequireStart().RequireStop()
+
+		err := app.Start(context.Background())
+		require.Error(t, err)
+		var stateErr *StateError
+		require.True(t, errors.As(err, &stateErr), "expected a StateError, got %v", err)
+		assert.Equal(t, "start", stateErr.Op)
+		assert.Equal(t, StateRunning, stateErr.State)
+	})
+
+	t.Run("StopBeforeStart", func(t *testing.T) {
+		app := fxtest.New(t)
+		err := app.Stop(context.Background())
+		assert.EqualError(t, err, "fx: cannot stop application: application is created")
+	})
+
+	t.Run("ConcurrentStart", func(t *testing.T) {
+		unblock := make(chan struct{})
+		starting := make(chan struct{})
+		app := fxtest.New(t, Invoke(func(lc Lifecycle) {
+			lc.Append(Hook{OnStart: func(context.Context) error {
+				close(starting)
+				<-unblock
+				return nil
+			}})
+		}))
+
+		errc := make(chan error, 1)
+		go func() { errc <- app.Start(context.Background()) }()
+		<-starting
+
+		err := app.Start(context.Background())
+		assert.EqualError(t, err, "fx: cannot start application: application is starting")
+		assert.EqualError(t, app.Stop(context.Background()), "fx: cannot stop application: application is starting")
+
+		close(unblock)
+		require.NoError(t, <-errc)
+		app.RequireStop()
+	})
+
+	t.Run("FailedStart", func(t *testing.T) {
+		app := fxtest.New(t, Invoke(func(lc Lifecycle) {
+			lc.Append(Hook{OnStart: func(context.Context) error {
+				return errors.New("great sadness")
+			}})
+		}))
+		require.Error(t, app.Start(context.Background()))
+		assert.Equal(t, StateStopped, app.State())
+	})
+
+	t.Run("StartTimeout", func(t *testing.T) {
+		var stopped bool
+		unblock := make(chan struct{})
+		app := fxtest.New(t, Invoke(func(lc Lifecycle) {
+			lc.Append(Hook{
+				OnStart: func(context.Context) error {
+					<-unblock
+					return nil
+				},
+				OnStop: func(context.Context) error {
+					stopped = true
+					return nil
+				},
+			})
+		}))
+		changes := app.StateChanges()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		var te *TimeoutError
+		require.True(t, errors.As(app.Start(ctx), &te), "expected a TimeoutError")
+		assert.Equal(t, StateStarting, app.State())
+
+		ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.True(t, errors.As(app.Stop(ctx), &te), "Stop must time out while the hook is stuck")
+
+		time.AfterFunc(10*time.Millisecond, func() { close(unblock) })
+		require.NoError(t, app.Stop(context.Background()), "Stop must wait for the rollback")
+
+		assert.True(t, stopped, "expected the started hook to be rolled back")
+		assert.Equal(t, StateStopped, app.State())
+		assert.Equal(t, StateStarting, <-changes)
+		assert.Equal(t, StateStopped, <-changes, "the application must never run")
+
+		app.RequireStart().RequireStop()
+	})
+
+	t.Run("FailedNew", func(t *testing.T) {
+		app := NewForTest(t, Error(errors.New("great sadness")))
+		assert.EqualError(t, app.Start(context.Background()), "great sadness")
+		assert.Equal(t, StateCreated, app.State())
+	})
+}
+
+func TestLifecyclePhases(t *testing.T) {
+	var events []string
+	record := func(e string) func(context.Context) error {
+		return func(context.Context) error {
+			events = append(events, e)
+			return nil
+		}
+	}
+
+	type Server struct{}
+	newServer := func(lc Lifecycle) *Server {
+		lc.Append(Hook{OnStart: record("listen"), OnStop: record("close")})
+		return &Server{}
+	}
+	readiness := func(lc Lifecycle, _ *Server) {
+		lc.Append(Hook{OnPostStart: record("ready"), OnPreStop: record("not ready")})
+	}
+	registry := func(lc Lifecycle) {
+		lc.Append(Hook{OnPreStart: record("configure"), OnPostStop: record("flush")})
+	}
+
+	spy := printerSpy{&bytes.Buffer{}}
+	app := fxtest.New(t,
+		Logger(spy),
+		Provide(newServer),
+		Invoke(readiness, registry),
+	)
+	app.RequireStart().RequireStop()
+
+	assert.Equal(t, []string{
+		"configure", "listen", "ready", "not ready", "close", "flush",
+	}, events)
+	for _, label := range []string{"PRESTART", "POSTSTART", "PRESTOP", "POSTSTOP"} {
+		assert.Contains(t, spy.String(), label+"\t")
+	}
+}
+
+func TestAppRestart(t *testing.T) {
+	t.Run("StartStopStart", func(t *testing.T) {
+		var events []string
+		app := fxtest.New(t, Invoke(func(lc Lifecycle) {
+			for _, name := range []string{"a", "b"} {
+				name := name
+				lc.Append(Hook{
+					OnStart: func(context.Context) error {
+						events = append(events, "start "+name)
+						return nil
+					},
+					OnStop: func(context.Context) error {
+						events = append(events, "stop "+name)
+						return nil
+					},
+				})
+			}
+		}))
+
+		app.RequireStart().RequireStop()
+		app.RequireStart().RequireStop()
+		assert.Equal(t, []string{
+			"start a", "start b", "stop b", "stop a",
+			"start a", "start b", "stop b", "stop a",
+		}, events)
+	})
+
+	t.Run("AfterPartialFailure", func(t *testing.T) {
+		var (
+			events []string
+			fail   = true
+		)
+		app := fxtest.New(t, Invoke(func(lc Lifecycle) {
+			lc.Append(Hook{
+				OnStart: func(context.Context) error {
+					events = append(events, "start a")
+					return nil
+				},
+				OnStop: func(context.Context) error {
+					events = append(events, "stop a")
+					return nil
+				},
+			})
+			lc.Append(Hook{
+				OnStart: func(context.Context) error {
+					if fail {
+						return errors.New("great sadness")
+					}
+					events = append(events, "start b")
+					return nil
+				},
+				OnStop: func(context.Context) error {
+					events = append(events, "stop b")
+					return nil
+				},
+			})
+		}))
+
+		err := app.Start(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "great sadness")
+		assert.Equal(t, StateStopped, app.State())
+		assert.Equal(t, []string{"start a", "stop a"}, events)
+
+		fail = false
+		events = nil
+		app.RequireStart().RequireStop()
+		assert.Equal(t, []string{"start a", "start b", "stop b", "stop a"}, events)
+	})
+
+	t.Run("AfterStopFailure", func(t *testing.T) {
+		var starts int
+		stopErr := errors.New("OnStop fail")
+		app := fxtest.New(t, Invoke(func(lc Lifecycle) {
+			lc.Append(Hook{
+				OnStart: func(context.Context) error {
+					starts++
+					return nil
+				},
+				OnStop: func(context.Context) error { return stopErr },
+			})
+		}))
+
+		app.RequireStart()
+		err := app.Stop(context.Background())
+		var he *HookError
+		require.True(t, errors.As(err, &he), "expected a HookError, got %v", err)
+		assert.Equal(t, HookStop, he.Phase)
+		assert.Equal(t, stopErr, he.Err)
+		assert.Equal(t, StateStopped, app.State())
+
+		app.RequireStart()
+		assert.Equal(t, 2, starts)
+	})
+}
+
+func TestLifecycleGo(t *testing.T) {
+	t.Run("StopsWithApp", func(t *testing.T) {
+		var stopped bool
+		app := fxtest.New(t, Invoke(func(lc Lifecycle) {
+			Go(lc, "loop", func(ctx context.Context) error {
+				<-ctx.Done()
+				stopped = true
+				return nil
+			})
+		}))
+
+		app.RequireStart().RequireStop()
+		assert.True(t, stopped, "worker wasn't stopped")
+	})
+
+	t.Run("FailureShutsDownApp", func(t *testing.T) {
+		giveUp := errors.New("great sadness")
+		app := fxtest.New(t, Invoke(func(lc Lifecycle) {
+			Go(lc, "loop", func(context.Context) error { return giveUp })
+		}))
+
+		done := app.Done()
+		wait := app.Wait()
+		app.RequireStart()
+		assert.Equal(t, syscall.SIGTERM, <-done)
+
+		sig := <-wait
+		assert.True(t, errors.Is(sig.Reason, giveUp), "expected the worker's error, got %v", sig.Reason)
+		assert.Contains(t, sig.Reason.Error(), `worker "loop" failed`)
+		assert.Contains(t, sig.Caller, "TestLifecycleGo.func")
+
+		err := app.Stop(context.Background())
+		assert.True(t, errors.Is(err, giveUp), "expected the worker's error, got %v", err)
+	})
+
+	t.Run("RestartPolicyExhausted", func(t *testing.T) {
+		var runs int
+		app := fxtest.New(t, Invoke(func(lc Lifecycle) {
+			Go(lc, "consumer", func(context.Context) error {
+				runs++
+				return errors.New("great sadness")
+			},
+				RestartOnFailure(),
+				RestartBackoff(time.Millisecond, time.Millisecond),
+				MaxRestarts(3, time.Minute),
+			)
+		}))
+
+		done := app.Done()
+		app.RequireStart()
+		assert.Equal(t, syscall.SIGTERM, <-done)
+
+		err := app.Stop(context.Background())
+		assert.Contains(t, err.Error(), `worker "consumer" gave up after 3 restarts within 1m0s: great sadness`)
+		assert.Equal(t, 4, runs)
+	})
+
+	t.Run("OtherLifecycle", func(t *testing.T) {
+		var lc hookRecorder
+		giveUp := errors.New("great sadness")
+		Go(&lc, "loop", func(context.Context) error { return giveUp })
+
+		require.Len(t, lc, 1)
+		require.NoError(t, lc[0].OnStart(context.Background()))
+		err := lc[0].OnStop(context.Background())
+		assert.True(t, errors.Is(err, giveUp), "expected the worker's error, got %v", err)
+	})
+}
+
+// hookRecorder is a Lifecycle that records the hooks appended to it.
+type hookRecorder []Hook
+
+func (r *hookRecorder) Append(h Hook) { *r = append(*r, h) }
+
+func TestParallelLifecycle(t *testing.T) {
+	type A struct{}
+	type B struct{}
+	type C struct{}
+
+	var (
+		mu     sync.Mutex
+		events []string
+	)
+	record := func(e string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+	}
+
+	// A and B are independent, so their OnStart hooks must run concurrently
+	// for either of them to complete.
+	aStarted, bStarted := make(chan struct{}), make(chan struct{})
+	newA := func(lc Lifecycle) A {
+		lc.Append(Hook{
+			OnStart: func(context.Context) error {
+				close(aStarted)
+				<-bStarted
+				record("start a")
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				record("stop a")
+				return nil
+			},
+		})
+		return A{}
+	}
+	newB := func(lc Lifecycle) B {
+		lc.Append(Hook{OnStart: func(context.Context) error {
+			close(bStarted)
+			<-aStarted
+			return nil
+		}})
+		return B{}
+	}
+	newC := func(lc Lifecycle, _ A) C {
+		lc.Append(Hook{
+			OnStart: func(context.Context) error {
+				record("start c")
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				record("stop c")
+				return nil
+			},
+		})
+		return C{}
+	}
+
+	app := fxtest.New(t,
+		ParallelLifecycle(),
+		Provide(newA, newB, newC),
+		Invoke(func(B, C) {}),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, app.Start(ctx))
+	require.NoError(t, app.Stop(ctx))
+	assert.Equal(t, []string{"start a", "start c", "stop c", "stop a"}, events)
+}
+
+func TestRecoverFromPanics(t *testing.T) {
+	type A struct{}
+
+	t.Run("Constructor", func(t *testing.T) {
+		newA := func() A { panic("great sadness") }
+
+		var handled error
+		app := NewForTest(t,
+			RecoverFromPanics(),
+			Provide(newA),
+			Invoke(func(A) {}),
+			ErrorHook(errHandlerFunc(func(err error) { handled = err })),
+		)
+
+		var pe *PanicError
+		require.True(t, errors.As(app.Err(), &pe), "expected a PanicError, got %v", app.Err())
+		assert.Equal(t, funcPath(newA), pe.Func)
+		assert.Equal(t, "great sadness", pe.Value)
+		assert.Contains(t, string(pe.Stack), "panic")
+		assert.Equal(t, app.Err(), handled)
+	})
+
+	t.Run("Invoke", func(t *testing.T) {
+		err := errors.New("great sadness")
+		run := func() { panic(err) }
+
+		app := NewForTest(t, RecoverFromPanics(), Invoke(run))
+
+		var pe *PanicError
+		require.True(t, errors.As(app.Err(), &pe), "expected a PanicError, got %v", app.Err())
+		assert.Equal(t, funcPath(run), pe.Func)
+		assert.True(t, errors.Is(app.Err(), err), "panic value must be unwrapped")
+		assert.Equal(t, "panic in "+funcPath(run)+"(): great sadness", app.Err().Error())
+	})
+
+	t.Run("OnStartRollsBack", func(t *testing.T) {
+		var (
+			stopped bool
+			handled error
+		)
+		app := fxtest.New(t,
+			RecoverFromPanics(),
+			ErrorHook(errHandlerFunc(func(err error) { handled = err })),
+			Invoke(func(lc Lifecycle) {
+				lc.Append(Hook{OnStop: func(context.Context) error {
+					stopped = true
+					return nil
+				}})
+				lc.Append(Hook{OnStart: func(context.Context) error {
+					panic("great sadness")
+				}})
+			}),
+		)
+
+		err := app.Start(context.Background())
+		var pe *PanicError
+		require.True(t, errors.As(err, &pe), "expected a PanicError, got %v", err)
+		assert.Contains(t, pe.Func, "TestRecoverFromPanics")
+		assert.True(t, stopped, "started hooks must be stopped")
+		assert.Equal(t, err, handled)
+		assert.Equal(t, StateStopped, app.State())
+	})
+
+	t.Run("OnStop", func(t *testing.T) {
+		var handled error
+		app := fxtest.New(t,
+			RecoverFromPanics(),
+			ErrorHook(errHandlerFunc(func(err error) { handled = err })),
+			Invoke(func(lc Lifecycle) {
+				lc.Append(Hook{OnStop: func(context.Context) error {
+					panic("great sadness")
+				}})
+			}),
+		)
+		app.RequireStart()
+
+		err := app.Stop(context.Background())
+		var pe *PanicError
+		require.True(t, errors.As(err, &pe), "expected a PanicError, got %v", err)
+		assert.Equal(t, err, handled)
+	})
+
+	t.Run("DisabledByDefault", func(t *testing.T) {
+		assert.Panics(t, func() {
+			NewForTest(t, Invoke(func() { panic("great sadness") }))
+		})
+	})
+}
+
+type autoServer struct {
+	events *[]string
+	name   string
+}
+
+func (s *autoServer) Start(context.Context) error {
+	*s.events = append(*s.events, "start "+s.name)
+	return nil
+}
+
+func (s *autoServer) Stop(context.Context) error {
+	*s.events = append(*s.events, "stop "+s.name)
+	return nil
+}
+
+type autoCloser struct{ events *[]string }
+
+func (c autoCloser) Close() error {
+	*c.events = append(*c.events, "close")
+	return nil
+}
+
+func TestAutoLifecycle(t *testing.T) {
+	t.Run("Option", func(t *testing.T) {
+		var events []string
+		newCloser := func() autoCloser { return autoCloser{&events} }
+		newServer := func(autoCloser) *autoServer { return &autoServer{&events, "server"} }
+
+		spy := printerSpy{&bytes.Buffer{}}
+		app := fxtest.New(t,
+			Logger(spy),
+			AutoLifecycle(),
+			Provide(newCloser, newServer),
+			Invoke(func(*autoServer) {}),
+		)
+		app.RequireStart().RequireStop()
+
+		assert.Equal(t, []string{"start server", "stop server", "close"}, events)
+		assert.Contains(t, spy.String(), "START\t\t"+funcPath(newServer)+"()")
+		assert.Contains(t, spy.String(), "STOP\t\t"+funcPath(newCloser)+"()")
+	})
+
+	t.Run("Annotated", func(t *testing.T) {
+		var events []string
+		app := fxtest.New(t,
+			Provide(
+				Annotated{
+					Name:          "auto",
+					Target:        func() *autoServer { return &autoServer{&events, "auto"} },
+					AutoLifecycle: true,
+				},
+				Annotated{
+					Name:   "manual",
+					Target: func() *autoServer { return &autoServer{&events, "manual"} },
+				},
+			),
+			Invoke(func(struct {
+				In
+
+				Auto   *autoServer `name:"auto"`
+				Manual *autoServer `name:"manual"`
+			}) {
+			}),
+		)
+		app.RequireStart().RequireStop()
+
+		assert.Equal(t, []string{"start auto", "stop auto"}, events)
+	})
+
+	t.Run("OutStruct", func(t *testing.T) {
+		type result struct {
+			Out
+
+			Primary   *autoServer `name:"primary"`
+			Secondary *autoServer `name:"secondary"`
+		}
+		var events []string
+		app := fxtest.New(t,
+			AutoLifecycle(),
+			Provide(func() result {
+				return result{Primary: &autoServer{&events, "primary"}}
+			}),
+			Invoke(func(struct {
+				In
+
+				Primary *autoServer `name:"primary"`
+			}) {
+			}),
+		)
+		app.RequireStart().RequireStop()
+
+		assert.Equal(t, []string{"start primary", "stop primary"}, events)
+	})
+
+	t.Run("Module", func(t *testing.T) {
+		var events []string
+		app := fxtest.New(t,
+			Module("servers",
+				AutoLifecycle(),
+				Provide(func() *autoServer { return &autoServer{&events, "server"} }),
+			),
+			Invoke(func(*autoServer) {}),
+		)
+		app.RequireStart().RequireStop()
+
+		assert.Equal(t, []string{"start server", "stop server"}, events)
+	})
+
+	t.Run("ConstructorError", func(t *testing.T) {
+		var events []string
+		app := NewForTest(t,
+			AutoLifecycle(),
+			Provide(func() (*autoServer, error) {
+				return &autoServer{&events, "server"}, errors.New("great sadness")
+			}),
+			Invoke(func(*autoServer) {}),
+		)
+		assert.Error(t, app.Err())
+		assert.Empty(t, events)
+	})
+}
+
+func funcPath(fn interface{}) string {
+	return strings.TrimSuffix(fxreflect.FuncName(fn), "()")
+}
+
+func TestLifecycleReports(t *testing.T) {
+	spy := printerSpy{&bytes.Buffer{}}
+	app := fxtest.New(t,
+		Logger(spy),
+		Invoke(func(lc Lifecycle) {
+			lc.Append(Hook{
+				OnStart: func(context.Context) error {
+					time.Sleep(10 * time.Millisecond)
+					return nil
+				},
+				OnStop: func(context.Context) error { return nil },
+			})
+		}),
+	)
+	app.RequireStart()
+
+	startup := app.StartupReport()
+	require.Len(t, startup.Hooks, 1)
+	assert.Equal(t, "OnStart", startup.Hooks[0].Phase)
+	assert.True(t, startup.Duration >= 10*time.Millisecond, "startup took %v", startup.Duration)
+	assert.Contains(t, spy.String(), fmt.Sprintf("RUNNING\t\tstart took %v", startup.Duration))
+	assert.Contains(t, spy.String(), fmt.Sprintf("() (took %v)", startup.Hooks[0].Duration))
+	assert.Equal(t, 1, strings.Count(spy.String(), "START\t\t"), "each hook must be logged once")
+
+	app.RequireStop()
+	shutdown := app.ShutdownReport()
+	require.Len(t, shutdown.Hooks, 1)
+	assert.Equal(t, "OnStop", shutdown.Hooks[0].Phase)
+	assert.Contains(t, spy.String(), fmt.Sprintf("STOPPED\t\tstop took %v", shutdown.Duration))
+}
+
+func TestRunE(t *testing.T) {
+	t.Run("StartError", func(t *testing.T) {
+		app := New(NopLogger, Invoke(func(lc Lifecycle) {
+			lc.Append(Hook{OnStart: func(context.Context) error {
+				return errors.New("great sadness")
+			}})
+		}))
+
+		err := app.RunE(context.Background())
+		require.Error(t, err)
+		var he *HookError
+		assert.True(t, errors.As(err, &he), "expected a HookError, got %v", err)
+		assert.Contains(t, err.Error(), "failed to start")
+	})
+
+	t.Run("StartTimeout", func(t *testing.T) {
+		var stopped bool
+		unblock := make(chan struct{})
+		app := New(NopLogger, StartTimeout(10*time.Millisecond), Invoke(func(lc Lifecycle) {
+			lc.Append(Hook{
+				OnStart: func(context.Context) error {
+					<-unblock
+					return nil
+				},
+				OnStop: func(context.Context) error {
+					stopped = true
+					return nil
+				},
+			})
+		}))
+		time.AfterFunc(50*time.Millisecond, func() { close(unblock) })
+
+		err := app.RunE(context.Background())
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded), "expected a deadline error, got %v", err)
+		assert.True(t, stopped, "RunE must roll back the hooks Start gave up on")
+		assert.Equal(t, StateStopped, app.State())
+	})
+
+	// cancelOnceRunning cancels the context once app is running.
+	cancelOnceRunning := func(app *App, cancel context.CancelFunc) {
+		changes := app.StateChanges()
+		go func() {
+			for s := range changes {
+				if s == StateRunning {
+					cancel()
+					return
+				}
+			}
+		}()
+	}
+
+	t.Run("StopError", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		app := New(NopLogger, Invoke(func(lc Lifecycle) {
+			lc.Append(Hook{OnStop: func(context.Context) error {
+				return errors.New("great sadness")
+			}})
+		}))
+		cancelOnceRunning(app, cancel)
+
+		err := app.RunE(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to stop cleanly")
+		assert.Contains(t, err.Error(), "great sadness")
+	})
+
+	t.Run("ContextCanceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		var stopped bool
+		app := New(NopLogger, Invoke(func(lc Lifecycle) {
+			lc.Append(Hook{OnStop: func(context.Context) error {
+				stopped = true
+				return nil
+			}})
+		}))
+		cancelOnceRunning(app, cancel)
+
+		assert.NoError(t, app.RunE(ctx))
+		assert.True(t, stopped)
+		assert.Equal(t, StateStopped, app.State())
+	})
+
+	t.Run("ExitCode", func(t *testing.T) {
+		var shutdowner Shutdowner
+		reason := errors.New("lost connection to the database")
+		app := New(NopLogger, Populate(&shutdowner), Invoke(func(lc Lifecycle) {
+			lc.Append(Hook{OnStart: func(context.Context) error {
+				return shutdowner.Shutdown(ExitCode(3), ShutdownReason(reason))
+			}})
+		}))
+
+		err := app.RunE(context.Background())
+		var exitErr *ExitError
+		require.True(t, errors.As(err, &exitErr), "expected an ExitError, got %v", err)
+		assert.Equal(t, 3, exitErr.Code)
+		assert.False(t, exitErr.Forced)
+		assert.True(t, errors.Is(err, reason))
+		assert.EqualError(t, err, "exit code 3: lost connection to the database")
+	})
+}
+
+func TestAppContext(t *testing.T) {
+	t.Run("CanceledOnStop", func(t *testing.T) {
+		var ctx AppContext
+		app := fxtest.New(t, AppName("billing"), Populate(&ctx))
+		name, ok := AppNameFromContext(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, "billing", name)
+
+		app.RequireStart()
+		assert.NoError(t, ctx.Err(), "context must not be canceled while running")
+		app.RequireStop()
+		assert.Equal(t, context.Canceled, ctx.Err())
+	})
+
+	t.Run("CanceledOnShutdown", func(t *testing.T) {
+		var (
+			ctx        AppContext
+			shutdowner Shutdowner
+		)
+		app := fxtest.New(t, Populate(&ctx, &shutdowner))
+		defer app.RequireStart().RequireStop()
+
+		name, _ := AppNameFromContext(ctx)
+		assert.NotEmpty(t, name, "the name must default to the executable's")
+		require.NoError(t, shutdowner.Shutdown())
+		assert.Equal(t, context.Canceled, ctx.Err())
+	})
+
+	t.Run("CanceledOnFailedStart", func(t *testing.T) {
+		var ctx AppContext
+		app := New(NopLogger, Populate(&ctx), Invoke(func(lc Lifecycle) {
+			lc.Append(Hook{OnStart: func(context.Context) error {
+				return errors.New("great sadness")
+			}})
+		}))
+		require.Error(t, app.Start(context.Background()))
+		assert.Equal(t, context.Canceled, ctx.Err())
+	})
+
+	t.Run("ApplicationContext", func(t *testing.T) {
+		type key struct{}
+		var (
+			ctx    context.Context
+			appCtx AppContext
+		)
+		app := fxtest.New(t,
+			Provide(func() context.Context {
+				return context.WithValue(context.Background(), key{}, "value")
+			}),
+			Populate(&ctx, &appCtx),
+		)
+		defer app.RequireStart().RequireStop()
+
+		assert.Equal(t, "value", ctx.Value(key{}))
+		_, ok := AppNameFromContext(appCtx)
+		assert.True(t, ok)
+	})
+}
+
+func TestReload(t *testing.T) {
+	var (
+		reloads int
+		handled []error
+		fail    bool
+		r       Reloader
+	)
+	reloadErr := errors.New("bad certificate")
+	app := fxtest.New(t,
+		Populate(&r),
+		ErrorHook(errHandlerFunc(func(err error) { handled = append(handled, err) })),
+		Invoke(func(lc Lifecycle) {
+			lc.Append(Hook{OnReload: func(context.Context) error {
+				reloads++
+				if fail {
+					return reloadErr
+				}
+				return nil
+			}})
+		}),
+	)
+
+	var se *StateError
+	require.True(t, errors.As(r.Reload(context.Background()), &se), "Reload must fail before Start")
+	assert.Equal(t, StateCreated, se.State)
+
+	app.RequireStart()
+	require.NoError(t, r.Reload(context.Background()))
+	assert.Equal(t, 1, reloads)
+
+	fail = true
+	err := r.Reload(context.Background())
+	var he *HookError
+	require.True(t, errors.As(err, &he), "expected a HookError, got %v", err)
+	assert.Equal(t, HookReload, he.Phase)
+	assert.Equal(t, []error{err}, handled)
+	assert.Equal(t, StateRunning, app.State(), "reload failures must not stop the application")
+
+	app.RequireStop()
+	assert.Equal(t, 2, reloads)
+}
+
+func TestReloadTimeout(t *testing.T) {
+	var (
+		reloads = make(chan struct{}, 3)
+		release = make(chan struct{})
+		r       Reloader
+	)
+	app := fxtest.New(t,
+		Populate(&r),
+		Invoke(func(lc Lifecycle) {
+			lc.Append(Hook{OnReload: func(context.Context) error {
+				reloads <- struct{}{}
+				<-release
+				return nil
+			}})
+		}),
+	)
+	app.RequireStart()
+	defer app.RequireStop()
+
+	reload := func(timeout time.Duration) error {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		return r.Reload(ctx)
+	}
+
+	var te *TimeoutError
+	require.True(t, errors.As(reload(10*time.Millisecond), &te), "expected a TimeoutError")
+
+	err := reload(10 * time.Millisecond)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Len(t, reloads, 1, "reload must wait for the one that timed out")
+
+	close(release)
+	require.NoError(t, reload(time.Second))
+	assert.Len(t, reloads, 2)
 }
 
 func TestDone(t *testing.T) {
@@ -671,6 +1681,32 @@ func TestErrorHook(t *testing.T) {
 	})
 }
 
+func TestModule(t *testing.T) {
+	t.Run("NestedInvoke", func(t *testing.T) {
+		type A struct{}
+		var invoked bool
+		app := fxtest.New(t,
+			Module("outer",
+				Provide(func() *A { return &A{} }),
+				Module("inner", Invoke(func(*A) { invoked = true })),
+			),
+		)
+		defer app.RequireStart().RequireStop()
+		assert.True(t, invoked, "functions invoked in nested modules must run")
+	})
+
+	t.Run("ProvideError", func(t *testing.T) {
+		type A struct{}
+		app := New(NopLogger,
+			Provide(func() *A { return &A{} }),
+			Module("child", Provide(func() *A { return &A{} })),
+		)
+		err := app.Err()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "already provided")
+	})
+}
+
 func TestDecorate(t *testing.T) {
 	var invoked bool
 	type A struct{ original bool }
@@ -0,0 +1,148 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fx
+
+import (
+	"context"
+	"io"
+	"reflect"
+
+	"go.uber.org/dig"
+
+	"go.uber.org/fx/internal/lifecycle"
+)
+
+// AutoLifecycle makes the application append Lifecycle hooks for the values
+// returned by its constructors, sparing them the usual boilerplate:
+//
+//	lc.Append(fx.Hook{OnStart: s.Start, OnStop: s.Stop})
+//
+// A value's Start(context.Context) error method becomes the hook's OnStart
+// callback. Its Stop(context.Context) error method becomes the hook's OnStop
+// callback or, failing that, its Close() error method, as for an io.Closer.
+// Hooks are attributed to the constructor that produced the value, and are
+// appended when the constructor returns successfully, so they run in
+// dependency order like hand-written hooks.
+//
+// Only results whose declared type has one of these methods are considered,
+// including the fields of fx.Out structs. To enable this for some
+// constructors only, use the AutoLifecycle field of Annotated instead.
+func AutoLifecycle() Option {
+	return optionFunc(func(app *App) {
+		app.autoLifecycle = true
+	})
+}
+
+type (
+	starter interface{ Start(context.Context) error }
+	stopper interface{ Stop(context.Context) error }
+)
+
+var (
+	_typeOfStarter = reflect.TypeOf((*starter)(nil)).Elem()
+	_typeOfStopper = reflect.TypeOf((*stopper)(nil)).Elem()
+	_typeOfCloser  = reflect.TypeOf((*io.Closer)(nil)).Elem()
+)
+
+// withLifecycleHooks wraps a constructor so that Lifecycle hooks are appended
+// for the values it returns, attributed to caller. Constructors without any
+// results that could have hooks are returned as-is.
+func (app *App) withLifecycleHooks(ctor interface{}, caller string) interface{} {
+	fv := reflect.ValueOf(ctor)
+	ft := fv.Type()
+	if ft.Kind() != reflect.Func {
+		return ctor
+	}
+
+	var hasHooks bool
+	for i := 0; i < ft.NumOut(); i++ {
+		hasHooks = hasHooks || mayHaveHooks(ft.Out(i))
+	}
+	if !hasHooks {
+		return ctor
+	}
+
+	return reflect.MakeFunc(ft, func(args []reflect.Value) []reflect.Value {
+		var results []reflect.Value
+		if ft.IsVariadic() {
+			results = fv.CallSlice(args)
+		} else {
+			results = fv.Call(args)
+		}
+
+		if n := len(results); n > 0 && ft.Out(n-1) == _typeOfError && !results[n-1].IsNil() {
+			return results
+		}
+		for _, r := range results {
+			app.appendLifecycleHooks(caller, r)
+		}
+		return results
+	}).Interface()
+}
+
+// mayHaveHooks reports whether values of type t, or the fields of t if it's
+// an fx.Out struct, have methods that AutoLifecycle turns into hooks.
+func mayHaveHooks(t reflect.Type) bool {
+	if dig.IsOut(t) {
+		for i := 0; i < t.NumField(); i++ {
+			if f := t.Field(i); f.PkgPath == "" && mayHaveHooks(f.Type) {
+				return true
+			}
+		}
+		return false
+	}
+	return t.Implements(_typeOfStarter) || t.Implements(_typeOfStopper) || t.Implements(_typeOfCloser)
+}
+
+// appendLifecycleHooks appends a hook for v, or for each field of v if it's
+// an fx.Out struct, attributed to caller.
+func (app *App) appendLifecycleHooks(caller string, v reflect.Value) {
+	t := v.Type()
+	if dig.IsOut(t) {
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).PkgPath == "" {
+				app.appendLifecycleHooks(caller, v.Field(i))
+			}
+		}
+		return
+	}
+	if !mayHaveHooks(t) {
+		return
+	}
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func:
+		if v.IsNil() {
+			return
+		}
+	}
+
+	var hook lifecycle.Hook
+	switch x := v.Interface().(type) {
+	case stopper:
+		hook.OnStop = x.Stop
+	case io.Closer:
+		hook.OnStop = func(context.Context) error { return x.Close() }
+	}
+	if s, ok := v.Interface().(starter); ok {
+		hook.OnStart = s.Start
+	}
+	app.root().lifecycle.AppendFrom(caller, hook)
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fx
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+)
+
+// AppName changes the name of the application, which the application's
+// context carries. It defaults to the base name of the executable.
+func AppName(name string) Option {
+	return optionFunc(func(app *App) {
+		app.name = name
+	})
+}
+
+// AppContext is the context.Context provided to all applications. See the
+// App documentation for details. It has its own type so that it doesn't
+// conflict with any context.Context the application provides itself.
+type AppContext context.Context
+
+type appNameKey struct{}
+
+// AppNameFromContext returns the name of the application that ctx belongs
+// to, if ctx derives from an application's AppContext.
+func AppNameFromContext(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(appNameKey{}).(string)
+	return name, ok
+}
+
+// defaultAppName returns the base name of the executable.
+func defaultAppName() string {
+	if len(os.Args) == 0 {
+		return ""
+	}
+	return filepath.Base(os.Args[0])
+}
+
+// context returns the application's context, which is provided to all
+// applications. See the App documentation for details.
+func (app *App) context() AppContext {
+	return app.ctx
+}
+
+// cancelContext cancels the application's context, if it isn't already.
+func (app *App) cancelContext() {
+	app.cancelCtx()
+}
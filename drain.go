@@ -0,0 +1,94 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fx
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DrainPeriod makes Stop shut the application down in two phases, giving
+// in-flight work time to drain. Stop first runs the OnPreStop callbacks, in
+// which servers should start failing readiness checks, then waits for the
+// drain period, and only then runs the OnStop hooks in reverse order.
+//
+// This suits platforms such as Kubernetes, which keep routing traffic to an
+// application for a few seconds after asking it to stop. A second shutdown
+// signal, or another call to Shutdown, during Stop cuts the drain period
+// short. Since the drain period counts toward the StopTimeout, the latter
+// should leave enough time for the OnStop hooks.
+//
+// The drain period is skipped when rolling back a failed Start.
+func DrainPeriod(d time.Duration) Option {
+	return optionFunc(func(app *App) {
+		app.drainPeriod = d
+	})
+}
+
+// drain waits for the drain period, unless the application is interrupted
+// by a shutdown signal or ctx expires first.
+func (app *App) drain(ctx context.Context) {
+	app.logger.Printf("DRAIN\t\tWaiting %v before stopping", app.drainPeriod)
+
+	t := time.NewTimer(app.drainPeriod)
+	defer t.Stop()
+
+	interrupted := make(chan struct{})
+	var once sync.Once
+	defer app.onInterrupt(func(ShutdownSignal) {
+		once.Do(func() { close(interrupted) })
+	})()
+
+	select {
+	case <-t.C:
+	case <-interrupted:
+		app.logger.Printf("DRAIN\t\tCut short by a shutdown signal")
+	case <-ctx.Done():
+	}
+}
+
+// interrupt handles a shutdown signal sent while the application may be
+// stopping, by calling the function registered with onInterrupt, if any.
+func (app *App) interrupt(s ShutdownSignal) {
+	app.interruptMu.Lock()
+	f := app.interruptFunc
+	app.interruptMu.Unlock()
+
+	if f != nil {
+		f(s)
+	}
+}
+
+// onInterrupt makes interrupt call f until the returned function is called,
+// which restores the previous function.
+func (app *App) onInterrupt(f func(ShutdownSignal)) (restore func()) {
+	app.interruptMu.Lock()
+	prev := app.interruptFunc
+	app.interruptFunc = f
+	app.interruptMu.Unlock()
+
+	return func() {
+		app.interruptMu.Lock()
+		app.interruptFunc = prev
+		app.interruptMu.Unlock()
+	}
+}
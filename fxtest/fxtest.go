@@ -139,10 +139,20 @@ func (l *Lifecycle) RequireStop() {
 	}
 }
 
+// Reload calls the OnReload hooks of the started hooks, in order.
+func (l *Lifecycle) Reload(ctx context.Context) error { return l.lc.Reload(ctx) }
+
 // Append registers a new Hook.
 func (l *Lifecycle) Append(h fx.Hook) {
 	l.lc.Append(lifecycle.Hook{
-		OnStart: h.OnStart,
-		OnStop:  h.OnStop,
+		OnStart:        h.OnStart,
+		OnStop:         h.OnStop,
+		OnStartTimeout: h.OnStartTimeout,
+		OnStopTimeout:  h.OnStopTimeout,
+		OnPreStart:     h.OnPreStart,
+		OnPostStart:    h.OnPostStart,
+		OnPreStop:      h.OnPreStop,
+		OnPostStop:     h.OnPostStop,
+		OnReload:       h.OnReload,
 	})
 }
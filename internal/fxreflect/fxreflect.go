@@ -133,6 +133,24 @@ func Caller() string {
 	return "n/a"
 }
 
+// CallStack returns the names of the functions on the calling goroutine's
+// stack, innermost first, formatted like Caller. Called from a deferred
+// function while panicking, it includes the frames of the panicking calls.
+func CallStack() []string {
+	pcs := make([]uintptr, 128)
+	n := runtime.Callers(2, pcs)
+
+	var names []string
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		f, more := frames.Next()
+		names = append(names, sanitize(f.Function))
+		if !more {
+			return names
+		}
+	}
+}
+
 // FuncName returns a funcs formatted name
 func FuncName(fn interface{}) string {
 	fnV := reflect.ValueOf(fn)
@@ -156,8 +174,10 @@ func shouldIgnoreFrame(f runtime.Frame) bool {
 	if strings.Contains(f.File, "_test.go") {
 		return false
 	}
-	if strings.Contains(f.File, "go.uber.org/fx") {
-		return true
-	}
-	return false
+	// Fully-qualified function names begin with the import path, which
+	// includes the vendor directory of a vendored copy of Fx. Match
+	// go.uber.org/fx and its subpackages, but not go.uber.org/fxfoo.
+	fn := vendorRe.ReplaceAllString(f.Function, "")
+	s := strings.TrimPrefix(fn, "go.uber.org/fx")
+	return len(s) < len(fn) && len(s) > 0 && (s[0] == '.' || s[0] == '/')
 }
@@ -23,6 +23,7 @@ package fxreflect
 import (
 	"errors"
 	"log"
+	"runtime"
 	"sync"
 	"testing"
 
@@ -121,3 +122,57 @@ func TestSanitizeFuncNames(t *testing.T) {
 		})
 	}
 }
+
+func TestShouldIgnoreFrame(t *testing.T) {
+	cases := []struct {
+		name  string
+		frame runtime.Frame
+		want  bool
+	}{
+		{
+			"fx",
+			runtime.Frame{Function: "go.uber.org/fx.(*App).Start", File: "/src/fx/app.go"},
+			true,
+		},
+		{
+			"fx subpackage",
+			runtime.Frame{Function: "go.uber.org/fx/internal/lifecycle.(*Lifecycle).Append", File: "/src/fx/internal/lifecycle/lifecycle.go"},
+			true,
+		},
+		{
+			"fx outside GOPATH",
+			runtime.Frame{Function: "go.uber.org/fx.New", File: "/home/user/fx/app.go"},
+			true,
+		},
+		{
+			"vendored fx",
+			runtime.Frame{Function: "github.com/acme/app/vendor/go.uber.org/fx.(*App).Start", File: "/src/github.com/acme/app/vendor/go.uber.org/fx/app.go"},
+			true,
+		},
+		{
+			"vendored package sharing the prefix",
+			runtime.Frame{Function: "github.com/acme/app/vendor/go.uber.org/fxfoo.New", File: "/src/github.com/acme/app/vendor/go.uber.org/fxfoo/foo.go"},
+			false,
+		},
+		{
+			"fx tests",
+			runtime.Frame{Function: "go.uber.org/fx_test.TestApp", File: "/src/fx/app_test.go"},
+			false,
+		},
+		{
+			"package sharing the prefix",
+			runtime.Frame{Function: "go.uber.org/fxfoo.New", File: "/src/go.uber.org/fxfoo/foo.go"},
+			false,
+		},
+		{
+			"user code",
+			runtime.Frame{Function: "main.main", File: "/src/go.uber.org/fx/example/main.go"},
+			false,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.want, shouldIgnoreFrame(c.frame))
+		})
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package lifecycle
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// HookPhase identifies what the lifecycle was doing when a hook failed.
+type HookPhase string
+
+// Phases reported by HookError.
+const (
+	// HookStart is used for failures of start-side callbacks, such as
+	// OnStart.
+	HookStart HookPhase = "start"
+
+	// HookStop is used for failures of stop-side callbacks, such as OnStop.
+	HookStop HookPhase = "stop"
+
+	// HookRollback is used for failures of stop-side callbacks while
+	// stopping after Start failed.
+	HookRollback HookPhase = "rollback"
+
+	// HookReload is used for failures of OnReload callbacks.
+	HookReload HookPhase = "reload"
+)
+
+// A HookError reports the failure of a hook callback.
+type HookError struct {
+	// Phase is what the lifecycle was doing when the callback failed.
+	Phase HookPhase
+
+	// Callback is the name of the callback that failed, such as "OnStart".
+	Callback string
+
+	// Caller is the function that appended the hook, in the format used by
+	// fxreflect.Caller.
+	Caller string
+
+	// Elapsed is how long the callback ran before failing.
+	Elapsed time.Duration
+
+	// Err is the error returned by the callback.
+	Err error
+}
+
+func (e *HookError) Error() string {
+	if e.Phase == HookRollback {
+		return fmt.Sprintf("%s hook added by %s() failed during rollback: %v", e.Callback, e.Caller, e.Err)
+	}
+	return fmt.Sprintf("%s hook added by %s() failed: %v", e.Callback, e.Caller, e.Err)
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+// WrapErrors makes the lifecycle wrap the errors of failed hook callbacks in
+// *HookError errors. Otherwise, they're returned as-is.
+func (l *Lifecycle) WrapErrors() {
+	l.mu.Lock()
+	l.wrapErrors = true
+	l.mu.Unlock()
+}
+
+// hookError returns the error to report for a callback for phase p that
+// failed after running for d.
+func (l *Lifecycle) hookError(p phase, caller string, d time.Duration, err error) error {
+	l.mu.Lock()
+	wrap, rollingBack := l.wrapErrors, l.rollingBack
+	l.mu.Unlock()
+
+	if !wrap {
+		var te *timeoutError
+		if errors.As(err, &te) {
+			return fmt.Errorf("%s hook added by %s() %w", p.name, caller, err)
+		}
+		return err
+	}
+
+	he := &HookError{Phase: p.kind, Callback: p.name, Caller: caller, Elapsed: d, Err: err}
+	if p.kind == HookStop && rollingBack {
+		he.Phase = HookRollback
+	}
+	return he
+}
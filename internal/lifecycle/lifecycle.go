@@ -22,18 +22,169 @@ package lifecycle
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"go.uber.org/fx/internal/fxlog"
 	"go.uber.org/fx/internal/fxreflect"
 	"go.uber.org/multierr"
 	"os"
+	"sync"
+	"time"
 )
 
 // A Hook is a pair of start and stop callbacks, either of which can be nil,
-// plus a string identifying the supplier of the hook.
+// plus a string identifying the supplier of the hook. If positive, the
+// timeouts bound the time each callback may take.
+//
+// The optional phase callbacks run in lifecycle-wide phases around the
+// OnStart and OnStop callbacks of all hooks. Start-side callbacks share
+// OnStartTimeout and stop-side callbacks share OnStopTimeout. OnReload is
+// called by Reload, without a timeout of its own.
 type Hook struct {
-	OnStart func(context.Context) error
-	OnStop  func(context.Context) error
-	caller  string
+	OnStart        func(context.Context) error
+	OnStop         func(context.Context) error
+	OnStartTimeout time.Duration
+	OnStopTimeout  time.Duration
+
+	OnPreStart  func(context.Context) error
+	OnPostStart func(context.Context) error
+	OnPreStop   func(context.Context) error
+	OnPostStop  func(context.Context) error
+
+	OnReload func(context.Context) error
+
+	caller string
+}
+
+// A phase selects one of the callbacks of hooks.
+type phase struct {
+	// Name of the callback, used in errors.
+	name string
+
+	// Prefix of the log line printed once each callback returns.
+	label string
+
+	callback func(Hook) func(context.Context) error
+
+	// Whether the callback is used to start or stop the lifecycle, or to
+	// reload it, which selects its timeout and how it's reported.
+	kind HookPhase
+}
+
+// Phases in the order in which they run on start; stop-side phases run in
+// reverse.
+var (
+	_preStart = phase{
+		name: "OnPreStart", label: "PRESTART\t", kind: HookStart,
+		callback: func(h Hook) func(context.Context) error { return h.OnPreStart },
+	}
+	_start = phase{
+		name: "OnStart", label: "START\t\t", kind: HookStart,
+		callback: func(h Hook) func(context.Context) error { return h.OnStart },
+	}
+	_postStart = phase{
+		name: "OnPostStart", label: "POSTSTART\t", kind: HookStart,
+		callback: func(h Hook) func(context.Context) error { return h.OnPostStart },
+	}
+	_preStop = phase{
+		name: "OnPreStop", label: "PRESTOP\t\t", kind: HookStop,
+		callback: func(h Hook) func(context.Context) error { return h.OnPreStop },
+	}
+	_stop = phase{
+		name: "OnStop", label: "STOP\t\t", kind: HookStop,
+		callback: func(h Hook) func(context.Context) error { return h.OnStop },
+	}
+	_postStop = phase{
+		name: "OnPostStop", label: "POSTSTOP\t", kind: HookStop,
+		callback: func(h Hook) func(context.Context) error { return h.OnPostStop },
+	}
+
+	// The drain period between the OnPreStop and OnStop phases, if any,
+	// which doesn't call any hook callback.
+	_drain = phase{name: "Drain", label: "DRAIN\t\t", kind: HookStop}
+
+	// OnReload callbacks run on their own, with Reload.
+	_reload = phase{
+		name: "OnReload", label: "RELOAD\t\t", kind: HookReload,
+		callback: func(h Hook) func(context.Context) error { return h.OnReload },
+	}
+)
+
+// run calls the hook's callback for phase p, if any. While the callback
+// runs, it's reported by StuckHooks. Once it returns, run logs it along with
+// its duration, which it also records.
+func (l *Lifecycle) run(ctx context.Context, p phase, logger Logger, h Hook) error {
+	fn := p.callback(h)
+	if fn == nil {
+		return nil
+	}
+	if l.recovering() {
+		fn = recoverPanics(h.caller, fn)
+	}
+	fn = l.track(p.name, h.caller, fn)
+
+	var timeout time.Duration
+	switch p.kind {
+	case HookStart:
+		timeout = h.OnStartTimeout
+	case HookStop:
+		timeout = h.OnStopTimeout
+	}
+
+	began := time.Now()
+	err := callHook(ctx, fn, timeout)
+	d := time.Since(began)
+
+	logger.Printf("%s%s() (took %v)", p.label, h.caller, d)
+	l.recordHook(p, h.caller, d)
+	if err != nil {
+		return l.hookError(p, h.caller, d, err)
+	}
+	return nil
+}
+
+// callHook calls fn, giving up once the timeout expires if it's positive. If
+// the hook's own timeout is what expired, it returns a *timeoutError.
+func callHook(ctx context.Context, fn func(context.Context) error, timeout time.Duration) error {
+	if timeout <= 0 {
+		return fn(ctx)
+	}
+
+	hookCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	c := make(chan error, 1)
+	go func() { c <- fn(hookCtx) }()
+
+	var err error
+	select {
+	case err = <-c:
+		if err == nil || hookCtx.Err() == nil {
+			return err
+		}
+	case <-hookCtx.Done():
+		err = hookCtx.Err()
+	}
+
+	if ctx.Err() != nil {
+		// The caller's deadline expired first.
+		return err
+	}
+	return &timeoutError{timeout: timeout, err: hookCtx.Err()}
+}
+
+// A timeoutError reports that a hook exceeded its own timeout.
+type timeoutError struct {
+	timeout time.Duration
+	err     error
+}
+
+func (e *timeoutError) Error() string {
+	return fmt.Sprintf("exceeded its %v timeout: %v", e.timeout, e.err)
+}
+
+func (e *timeoutError) Unwrap() error {
+	return e.err
 }
 
 type Logger interface {
@@ -45,11 +196,48 @@ type Logger interface {
 	Fatalf(format string, v ...interface{})
 }
 
+// A DependencyFunc reports whether hooks appended by caller must wait for
+// hooks appended by dep. Both arguments are function names as reported by
+// fxreflect.Caller. Implementations should return true if they don't know
+// either of the two functions.
+type DependencyFunc func(caller, dep string) bool
+
 // Lifecycle coordinates application lifecycle hooks.
 type Lifecycle struct {
-	logger     Logger
-	hooks      []Hook
-	numStarted int
+	logger Logger
+
+	// mu guards the hooks and the record of which hooks started. It's never
+	// held while a hook runs, so hooks may append other hooks.
+	mu    sync.Mutex
+	hooks []Hook
+
+	// Number of hooks that got through each start-side phase, whose
+	// stop-side counterparts must run on Stop.
+	numPreStarted  int
+	numStarted     int
+	numPostStarted int
+
+	// If non-nil, hooks are run in parallel as allowed by dependsOn, and
+	// started tracks which hooks' OnStart succeeded.
+	dependsOn DependencyFunc
+	started   []bool
+
+	workerFailed  func(string, error)
+	recoverPanics bool
+	drain         func(context.Context)
+
+	// Hook callbacks that are currently running.
+	running map[*runningHook]struct{}
+
+	// Timings of the last Start and Stop.
+	startReport Report
+	stopReport  Report
+
+	// Whether hook errors are wrapped in *HookError, and whether Stop is
+	// rolling back a failed Start.
+	wrapErrors  bool
+	startFailed bool
+	rollingBack bool
 }
 
 // New constructs a new Lifecycle.
@@ -62,40 +250,162 @@ func New(logger Logger) *Lifecycle {
 
 // Append adds a Hook to the lifecycle.
 func (l *Lifecycle) Append(hook Hook) {
-	hook.caller = fxreflect.Caller()
+	l.AppendFrom(fxreflect.Caller(), hook)
+}
+
+// AppendFrom adds a Hook to the lifecycle on behalf of the named function,
+// which is reported in logs and errors instead of the caller of AppendFrom.
+func (l *Lifecycle) AppendFrom(caller string, hook Hook) {
+	hook.caller = caller
+
+	l.mu.Lock()
 	l.hooks = append(l.hooks, hook)
+	l.mu.Unlock()
 }
 
-// Start runs all OnStart hooks, returning immediately if it encounters an
-// error.
-func (l *Lifecycle) Start(ctx context.Context) error {
-	for _, hook := range l.hooks {
-		if hook.OnStart != nil {
-			l.logger.Printf("START\t\t%s()", hook.caller)
-			if err := hook.OnStart(ctx); err != nil {
-				return err
-			}
+// Parallelize makes the lifecycle run hooks concurrently. A hook's OnStart
+// runs only after the OnStart of every earlier hook it depends on, and its
+// OnStop runs only after the OnStop of every later hook that depends on it.
+// Hooks appended by the same function always run in order.
+func (l *Lifecycle) Parallelize(f DependencyFunc) {
+	l.dependsOn = f
+}
+
+// OnDrain registers a function that Stop calls between the OnPreStop and
+// OnStop phases, typically to wait for in-flight work to drain. The function
+// should return once ctx expires.
+func (l *Lifecycle) OnDrain(f func(ctx context.Context)) {
+	l.mu.Lock()
+	l.drain = f
+	l.mu.Unlock()
+}
+
+// Start runs the OnPreStart, OnStart, and OnPostStart phases in turn. Each
+// phase runs the corresponding callback of all hooks, and Start returns
+// immediately if it encounters an error. Once Stop has run, the lifecycle
+// may be started again.
+func (l *Lifecycle) Start(ctx context.Context) (err error) {
+	l.mu.Lock()
+	running := l.numPreStarted > 0 || l.numStarted > 0 || l.started != nil || l.numPostStarted > 0
+	l.mu.Unlock()
+	if running {
+		return errors.New("lifecycle already started: Stop must be called before starting it again")
+	}
+
+	defer l.beginReport(&l.startReport)()
+	defer func() {
+		l.mu.Lock()
+		l.startFailed = err != nil
+		l.mu.Unlock()
+	}()
+
+	hooks := l.snapshot()
+	l.timePhase(&l.startReport, _preStart, func() {
+		err = l.startPhase(ctx, _preStart, hooks, &l.numPreStarted)
+	})
+	if err != nil {
+		return err
+	}
+
+	l.timePhase(&l.startReport, _start, func() {
+		if l.dependsOn != nil {
+			err = l.startParallel(ctx, hooks)
+		} else {
+			err = l.startPhase(ctx, _start, hooks, &l.numStarted)
 		}
-		l.numStarted++
+	})
+	if err != nil {
+		return err
 	}
-	return nil
+
+	l.timePhase(&l.startReport, _postStart, func() {
+		err = l.startPhase(ctx, _postStart, hooks, &l.numPostStarted)
+	})
+	return err
 }
 
-// Stop runs any OnStop hooks whose OnStart counterpart succeeded. OnStop
-// hooks run in reverse order.
+// Stop runs the OnPreStop, OnStop, and OnPostStop phases in turn. Each phase
+// runs in reverse order, and only for hooks whose OnPostStart, OnStart, and
+// OnPreStart counterpart (respectively) succeeded. If a drain function was
+// registered with OnDrain, it's called between the OnPreStop and OnStop
+// phases, unless Stop is rolling back a failed Start.
 func (l *Lifecycle) Stop(ctx context.Context) error {
+	defer l.beginReport(&l.stopReport)()
+
+	l.mu.Lock()
+	l.rollingBack, l.startFailed = l.startFailed, false
+	l.mu.Unlock()
+
+	// For best-effort cleanup, keep going after errors.
+	var errs []error
+	l.timePhase(&l.stopReport, _preStop, func() {
+		errs = append(errs, l.stopPhase(ctx, _preStop, &l.numPostStarted)...)
+	})
+
+	l.mu.Lock()
+	drain := l.drain
+	if l.rollingBack || (l.numStarted == 0 && l.started == nil) {
+		// Nothing was serving, so there's nothing to drain.
+		drain = nil
+	}
+	l.mu.Unlock()
+	if drain != nil {
+		l.timePhase(&l.stopReport, _drain, func() { drain(ctx) })
+	}
+
+	l.timePhase(&l.stopReport, _stop, func() {
+		if l.dependsOn != nil {
+			errs = append(errs, l.stopParallel(ctx))
+		} else {
+			errs = append(errs, l.stopPhase(ctx, _stop, &l.numStarted)...)
+		}
+	})
+
+	l.timePhase(&l.stopReport, _postStop, func() {
+		errs = append(errs, l.stopPhase(ctx, _postStop, &l.numPreStarted)...)
+	})
+	return multierr.Combine(errs...)
+}
+
+// startPhase runs a start-side phase for the given hooks in order, counting
+// the hooks it gets through in *n.
+func (l *Lifecycle) startPhase(ctx context.Context, p phase, hooks []Hook, n *int) error {
+	for _, hook := range hooks {
+		if err := l.run(ctx, p, l.logger, hook); err != nil {
+			return err
+		}
+
+		l.mu.Lock()
+		*n++
+		l.mu.Unlock()
+	}
+	return nil
+}
+
+// stopPhase runs a stop-side phase backward from the last of the *n hooks
+// that got through its start-side counterpart.
+func (l *Lifecycle) stopPhase(ctx context.Context, p phase, n *int) []error {
 	var errs []error
-	// Run backward from last successful OnStart.
-	for ; l.numStarted > 0; l.numStarted-- {
-		hook := l.hooks[l.numStarted-1]
-		if hook.OnStop == nil {
-			continue
+	for {
+		l.mu.Lock()
+		if *n == 0 {
+			l.mu.Unlock()
+			break
 		}
-		l.logger.Printf("STOP\t\t%s()", hook.caller)
-		if err := hook.OnStop(ctx); err != nil {
-			// For best-effort cleanup, keep going after errors.
+		hook := l.hooks[*n-1]
+		*n--
+		l.mu.Unlock()
+
+		if err := l.run(ctx, p, l.logger, hook); err != nil {
 			errs = append(errs, err)
 		}
 	}
-	return multierr.Combine(errs...)
+	return errs
+}
+
+// snapshot returns the hooks appended so far.
+func (l *Lifecycle) snapshot() []Hook {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.hooks[:len(l.hooks):len(l.hooks)]
 }
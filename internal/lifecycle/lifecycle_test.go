@@ -24,10 +24,12 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"go.uber.org/fx/internal/fxlog"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/multierr"
 )
 
@@ -102,6 +104,422 @@ func TestLifecycleStart(t *testing.T) {
 	})
 }
 
+func TestLifecyclePhases(t *testing.T) {
+	var events []string
+	recordingHook := func(name string, failStart, failPostStart bool) Hook {
+		record := func(phase string, fail bool) func(context.Context) error {
+			return func(context.Context) error {
+				events = append(events, phase+" "+name)
+				if fail {
+					return errors.New(phase + " failed")
+				}
+				return nil
+			}
+		}
+		return Hook{
+			OnPreStart:  record("prestart", false),
+			OnStart:     record("start", failStart),
+			OnPostStart: record("poststart", failPostStart),
+			OnPreStop:   record("prestop", false),
+			OnStop:      record("stop", false),
+			OnPostStop:  record("poststop", false),
+		}
+	}
+
+	t.Run("RunInOrder", func(t *testing.T) {
+		events = nil
+		l := New(nil)
+		l.Append(recordingHook("a", false, false))
+		l.Append(recordingHook("b", false, false))
+
+		require.NoError(t, l.Start(context.Background()))
+		require.NoError(t, l.Stop(context.Background()))
+		assert.Equal(t, []string{
+			"prestart a", "prestart b",
+			"start a", "start b",
+			"poststart a", "poststart b",
+			"prestop b", "prestop a",
+			"stop b", "stop a",
+			"poststop b", "poststop a",
+		}, events)
+	})
+
+	t.Run("StartFailureRollsBack", func(t *testing.T) {
+		events = nil
+		l := New(nil)
+		l.Append(recordingHook("a", false, false))
+		l.Append(recordingHook("b", true, false))
+
+		assert.EqualError(t, l.Start(context.Background()), "start failed")
+		require.NoError(t, l.Stop(context.Background()))
+		assert.Equal(t, []string{
+			"prestart a", "prestart b",
+			"start a", "start b",
+			"stop a",
+			"poststop b", "poststop a",
+		}, events)
+	})
+
+	t.Run("PostStartFailureRollsBack", func(t *testing.T) {
+		events = nil
+		l := New(nil)
+		l.Append(recordingHook("a", false, false))
+		l.Append(recordingHook("b", false, true))
+
+		assert.EqualError(t, l.Start(context.Background()), "poststart failed")
+		require.NoError(t, l.Stop(context.Background()))
+		assert.Equal(t, []string{
+			"prestart a", "prestart b",
+			"start a", "start b",
+			"poststart a", "poststart b",
+			"prestop a",
+			"stop b", "stop a",
+			"poststop b", "poststop a",
+		}, events)
+	})
+
+	t.Run("PhaseTimeoutNamesCallback", func(t *testing.T) {
+		l := New(nil)
+		l.Append(Hook{
+			OnPostStart: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			OnStartTimeout: time.Millisecond,
+		})
+
+		err := l.Start(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "OnPostStart hook added by")
+	})
+
+	t.Run("DrainBetweenPreStopAndStop", func(t *testing.T) {
+		events = nil
+		l := New(nil)
+		l.OnDrain(func(context.Context) { events = append(events, "drain") })
+		l.Append(recordingHook("a", false, false))
+
+		require.NoError(t, l.Start(context.Background()))
+		require.NoError(t, l.Stop(context.Background()))
+		assert.Equal(t, []string{
+			"prestart a", "start a", "poststart a",
+			"prestop a", "drain", "stop a", "poststop a",
+		}, events)
+
+		var phases []string
+		for _, p := range l.StopReport().Phases {
+			phases = append(phases, p.Phase)
+		}
+		assert.Equal(t, []string{"OnPreStop", "Drain", "OnStop", "OnPostStop"}, phases)
+	})
+
+	t.Run("NoDrainOnRollback", func(t *testing.T) {
+		events = nil
+		l := New(nil)
+		l.OnDrain(func(context.Context) { events = append(events, "drain") })
+		l.Append(recordingHook("a", false, true))
+
+		assert.Error(t, l.Start(context.Background()))
+		require.NoError(t, l.Stop(context.Background()))
+		assert.NotContains(t, events, "drain")
+	})
+}
+
+func TestLifecycleRecoverFromPanics(t *testing.T) {
+	t.Run("Hook", func(t *testing.T) {
+		l := New(nil)
+		l.RecoverFromPanics()
+		l.Append(Hook{
+			OnStart: func(context.Context) error { panic("great sadness") },
+		})
+
+		err := l.Start(context.Background())
+		var pe *PanicError
+		require.True(t, errors.As(err, &pe), "expected a PanicError, got %v", err)
+		assert.Equal(t, "great sadness", pe.Value)
+		assert.Contains(t, pe.Func, "TestLifecycleRecoverFromPanics")
+	})
+
+	t.Run("HookWithTimeout", func(t *testing.T) {
+		// Hooks with a timeout run in their own goroutine.
+		l := New(nil)
+		l.RecoverFromPanics()
+		l.Append(Hook{
+			OnStop:        func(context.Context) error { panic("great sadness") },
+			OnStopTimeout: time.Second,
+		})
+
+		require.NoError(t, l.Start(context.Background()))
+		err := l.Stop(context.Background())
+		var pe *PanicError
+		require.True(t, errors.As(err, &pe), "expected a PanicError, got %v", err)
+		assert.Equal(t, "great sadness", pe.Value)
+	})
+}
+
+func TestLifecycleStuckHooks(t *testing.T) {
+	l := New(nil)
+	assert.Empty(t, l.StuckHooks())
+
+	running, block := make(chan struct{}), make(chan struct{})
+	l.Append(Hook{OnStart: func(context.Context) error {
+		close(running)
+		<-block
+		return nil
+	}})
+
+	errc := make(chan error, 1)
+	go func() { errc <- l.Start(context.Background()) }()
+	<-running
+
+	hooks := l.StuckHooks()
+	require.Len(t, hooks, 1)
+	assert.Equal(t, "OnStart", hooks[0].Phase)
+	assert.Contains(t, hooks[0].Caller, "TestLifecycleStuckHooks")
+	assert.Contains(t, hooks[0].Stack, "TestLifecycleStuckHooks")
+	assert.Contains(t, hooks[0].String(), "OnStart hook added by")
+
+	close(block)
+	require.NoError(t, <-errc)
+	assert.Empty(t, l.StuckHooks())
+}
+
+func TestLifecyclePendingStops(t *testing.T) {
+	nop := func(context.Context) error { return nil }
+
+	t.Run("Sequential", func(t *testing.T) {
+		l := New(nil)
+		l.Append(Hook{OnStop: nop, OnPostStop: nop})
+		l.Append(Hook{OnPreStop: nop})
+		l.Append(Hook{OnStart: func(context.Context) error { return errors.New("fail") }, OnStop: nop})
+
+		assert.Empty(t, l.PendingStops())
+		assert.Error(t, l.Start(context.Background()))
+
+		var pending []string
+		for _, h := range l.PendingStops() {
+			pending = append(pending, h.Phase)
+			assert.Contains(t, h.String(), "hook added by go.uber.org/fx/internal/lifecycle.TestLifecyclePendingStops")
+		}
+		assert.Equal(t, []string{"OnStop", "OnPostStop"}, pending, "only callbacks of hooks that got through the counterpart start phase are pending")
+
+		require.NoError(t, l.Stop(context.Background()))
+		assert.Empty(t, l.PendingStops())
+	})
+
+	t.Run("Parallel", func(t *testing.T) {
+		l := New(nil)
+		l.Parallelize(func(string, string) bool { return false })
+
+		var pending []PendingHook
+		l.Append(Hook{OnStart: nop, OnStop: nop})
+		l.Append(Hook{OnStart: nop, OnStop: func(context.Context) error {
+			pending = l.PendingStops()
+			return nil
+		}})
+
+		require.NoError(t, l.Start(context.Background()))
+		assert.Len(t, l.PendingStops(), 2)
+		require.NoError(t, l.Stop(context.Background()))
+		assert.Empty(t, l.PendingStops())
+		// Hooks appended by the same caller stop in reverse order.
+		require.Len(t, pending, 1)
+		assert.Equal(t, "OnStop", pending[0].Phase)
+	})
+}
+
+func TestLifecycleReport(t *testing.T) {
+	sleep := func(d time.Duration) func(context.Context) error {
+		return func(context.Context) error {
+			time.Sleep(d)
+			return nil
+		}
+	}
+
+	l := New(nil)
+	l.Append(Hook{OnStart: sleep(10 * time.Millisecond), OnPostStop: sleep(time.Millisecond)})
+	l.Append(Hook{OnStop: sleep(5 * time.Millisecond)})
+
+	require.NoError(t, l.Start(context.Background()))
+	start := l.StartReport()
+	require.Len(t, start.Hooks, 1)
+	assert.Equal(t, "OnStart", start.Hooks[0].Phase)
+	assert.Contains(t, start.Hooks[0].Caller, "TestLifecycleReport")
+	assert.True(t, start.Hooks[0].Duration >= 10*time.Millisecond, "hook took %v", start.Hooks[0].Duration)
+
+	var phases []string
+	for _, p := range start.Phases {
+		phases = append(phases, p.Phase)
+	}
+	assert.Equal(t, []string{"OnPreStart", "OnStart", "OnPostStart"}, phases)
+	assert.True(t, start.Phases[1].Duration >= start.Hooks[0].Duration)
+	assert.True(t, start.Duration >= start.Phases[1].Duration)
+	assert.Empty(t, l.StopReport().Hooks)
+
+	require.NoError(t, l.Stop(context.Background()))
+	stop := l.StopReport()
+	require.Len(t, stop.Hooks, 2)
+	assert.Equal(t, "OnStop", stop.Hooks[0].Phase)
+	assert.Equal(t, "OnPostStop", stop.Hooks[1].Phase)
+	assert.True(t, stop.Duration >= 6*time.Millisecond, "stop took %v", stop.Duration)
+	assert.Equal(t, start, l.StartReport(), "Stop must not change the start report")
+}
+
+func TestLifecycleWrapErrors(t *testing.T) {
+	t.Run("StopErrors", func(t *testing.T) {
+		l := New(nil)
+		l.WrapErrors()
+
+		err1 := errors.New("some stop error")
+		err2 := errors.New("some other stop error")
+		l.Append(Hook{OnStop: func(context.Context) error { return err1 }})
+		l.Append(Hook{OnStop: func(context.Context) error { return err2 }})
+
+		require.NoError(t, l.Start(context.Background()))
+		errs := multierr.Errors(l.Stop(context.Background()))
+		require.Len(t, errs, 2)
+		for i, want := range []error{err2, err1} {
+			var he *HookError
+			require.True(t, errors.As(errs[i], &he), "expected a HookError, got %v", errs[i])
+			assert.Equal(t, HookStop, he.Phase)
+			assert.Equal(t, "OnStop", he.Callback)
+			assert.Contains(t, he.Caller, "TestLifecycleWrapErrors")
+			assert.Equal(t, want, he.Err)
+		}
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		l := New(nil)
+		l.WrapErrors()
+
+		startErr := errors.New("start failed")
+		stopErr := errors.New("stop failed")
+		l.Append(Hook{OnStop: func(context.Context) error { return stopErr }})
+		fail := true
+		l.Append(Hook{OnStart: func(context.Context) error {
+			time.Sleep(time.Millisecond)
+			if fail {
+				return startErr
+			}
+			return nil
+		}})
+
+		err := l.Start(context.Background())
+		var he *HookError
+		require.True(t, errors.As(err, &he), "expected a HookError, got %v", err)
+		assert.Equal(t, HookStart, he.Phase)
+		assert.True(t, he.Elapsed >= time.Millisecond, "hook took %v", he.Elapsed)
+		assert.True(t, errors.Is(err, startErr))
+
+		err = l.Stop(context.Background())
+		require.True(t, errors.As(err, &he), "expected a HookError, got %v", err)
+		assert.Equal(t, HookRollback, he.Phase)
+		assert.Contains(t, err.Error(), "OnStop hook added by")
+		assert.Contains(t, err.Error(), "failed during rollback: stop failed")
+
+		// Once rolled back, the lifecycle starts afresh.
+		fail = false
+		require.NoError(t, l.Start(context.Background()))
+		err = l.Stop(context.Background())
+		require.True(t, errors.As(err, &he), "expected a HookError, got %v", err)
+		assert.Equal(t, HookStop, he.Phase)
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		l := New(nil)
+		l.WrapErrors()
+		l.Append(Hook{
+			OnStart: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			OnStartTimeout: time.Millisecond,
+		})
+
+		err := l.Start(context.Background())
+		var he *HookError
+		require.True(t, errors.As(err, &he), "expected a HookError, got %v", err)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.Contains(t, err.Error(), "failed: exceeded its 1ms timeout")
+	})
+}
+
+func TestLifecycleReload(t *testing.T) {
+	l := New(nil)
+	l.WrapErrors()
+
+	var reloads []string
+	reloadErr := errors.New("bad certificate")
+	l.Append(Hook{OnReload: func(context.Context) error {
+		reloads = append(reloads, "a")
+		return reloadErr
+	}})
+	l.Append(Hook{})
+	l.Append(Hook{OnReload: func(context.Context) error {
+		reloads = append(reloads, "c")
+		return nil
+	}})
+
+	assert.Error(t, l.Reload(context.Background()), "Reload must fail before Start")
+	assert.Empty(t, reloads)
+	assert.False(t, l.Reloadable(), "hooks that didn't start can't reload")
+
+	require.NoError(t, l.Start(context.Background()))
+	assert.True(t, l.Reloadable())
+	err := l.Reload(context.Background())
+	assert.Equal(t, []string{"a", "c"}, reloads, "Reload must keep going after errors")
+
+	var he *HookError
+	require.True(t, errors.As(err, &he), "expected a HookError, got %v", err)
+	assert.Equal(t, HookReload, he.Phase)
+	assert.Equal(t, "OnReload", he.Callback)
+	assert.Equal(t, reloadErr, he.Err)
+	assert.Empty(t, l.StartReport().Hooks, "reloads must not be recorded as starts")
+
+	require.NoError(t, l.Stop(context.Background()))
+	assert.Error(t, l.Reload(context.Background()), "Reload must fail after Stop")
+	assert.False(t, l.Reloadable())
+
+	l = New(nil)
+	l.Append(Hook{OnStart: func(context.Context) error { return nil }})
+	require.NoError(t, l.Start(context.Background()))
+	assert.False(t, l.Reloadable(), "hooks without OnReload can't reload")
+}
+
+func TestLifecycleRestart(t *testing.T) {
+	t.Run("StartAfterStop", func(t *testing.T) {
+		l := New(nil)
+		var starts, stops int
+		l.Append(Hook{
+			OnStart: func(context.Context) error {
+				starts++
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				stops++
+				return nil
+			},
+		})
+
+		for i := 0; i < 2; i++ {
+			require.NoError(t, l.Start(context.Background()))
+			require.NoError(t, l.Stop(context.Background()))
+		}
+		assert.Equal(t, 2, starts)
+		assert.Equal(t, 2, stops)
+	})
+
+	t.Run("StartTwiceFails", func(t *testing.T) {
+		l := New(nil)
+		l.Append(Hook{})
+
+		require.NoError(t, l.Start(context.Background()))
+		assert.Error(t, l.Start(context.Background()))
+		require.NoError(t, l.Stop(context.Background()))
+		assert.NoError(t, l.Start(context.Background()))
+	})
+}
+
 func TestLifecycleStop(t *testing.T) {
 	t.Run("DoesNothingWithoutHooks", func(t *testing.T) {
 		l := &Lifecycle{logger: fxlog.New()}
@@ -213,3 +631,181 @@ func TestLifecycleStop(t *testing.T) {
 		l.Stop(context.Background())
 	})
 }
+
+func TestLifecycleParallel(t *testing.T) {
+	// Hooks appended from within the subtests share a caller, so they use
+	// hookFrom to simulate hooks appended by different constructors.
+	hookFrom := func(caller string, h Hook) Hook {
+		h.caller = caller
+		return h
+	}
+	independent := func(caller, dep string) bool { return false }
+
+	t.Run("StartsIndependentHooksConcurrently", func(t *testing.T) {
+		l := New(nil)
+		l.Parallelize(independent)
+
+		ready := make(chan struct{})
+		l.hooks = append(l.hooks,
+			hookFrom("a", Hook{OnStart: func(context.Context) error {
+				<-ready // blocks until b starts
+				return nil
+			}}),
+			hookFrom("b", Hook{OnStart: func(context.Context) error {
+				close(ready)
+				return nil
+			}}),
+		)
+
+		assert.NoError(t, l.Start(context.Background()))
+		assert.NoError(t, l.Stop(context.Background()))
+	})
+
+	t.Run("RespectsDependencies", func(t *testing.T) {
+		l := New(nil)
+		l.Parallelize(func(caller, dep string) bool {
+			return caller == "b" && dep == "a"
+		})
+
+		var events []string
+		record := func(e string) func(context.Context) error {
+			return func(context.Context) error {
+				events = append(events, e)
+				return nil
+			}
+		}
+		l.hooks = append(l.hooks,
+			hookFrom("a", Hook{OnStart: record("start a"), OnStop: record("stop a")}),
+			hookFrom("b", Hook{OnStart: record("start b"), OnStop: record("stop b")}),
+		)
+
+		assert.NoError(t, l.Start(context.Background()))
+		assert.NoError(t, l.Stop(context.Background()))
+		assert.Equal(t, []string{"start a", "start b", "stop b", "stop a"}, events)
+	})
+
+	t.Run("SameCallerRunsInOrder", func(t *testing.T) {
+		l := New(nil)
+		l.Parallelize(independent)
+
+		var events []string
+		record := func(e string) func(context.Context) error {
+			return func(context.Context) error {
+				events = append(events, e)
+				return nil
+			}
+		}
+		l.Append(Hook{OnStart: record("start 1"), OnStop: record("stop 1")})
+		l.Append(Hook{OnStart: record("start 2"), OnStop: record("stop 2")})
+
+		assert.NoError(t, l.Start(context.Background()))
+		assert.NoError(t, l.Stop(context.Background()))
+		assert.Equal(t, []string{"start 1", "start 2", "stop 2", "stop 1"}, events)
+	})
+
+	t.Run("RollsBackOnlyStartedHooks", func(t *testing.T) {
+		l := New(nil)
+		l.Parallelize(func(caller, dep string) bool {
+			return caller == "c" && dep == "b"
+		})
+
+		err := errors.New("b failed")
+		var stopped []string
+		stopper := func(name string) func(context.Context) error {
+			return func(context.Context) error {
+				stopped = append(stopped, name)
+				return nil
+			}
+		}
+		aStarted := make(chan struct{})
+		l.hooks = append(l.hooks,
+			hookFrom("a", Hook{
+				OnStart: func(context.Context) error {
+					close(aStarted)
+					return nil
+				},
+				OnStop: stopper("a"),
+			}),
+			hookFrom("b", Hook{
+				OnStart: func(context.Context) error {
+					<-aStarted
+					return err
+				},
+				OnStop: stopper("b"),
+			}),
+			hookFrom("c", Hook{
+				OnStart: func(context.Context) error {
+					t.Error("c depends on b and should never start")
+					return nil
+				},
+				OnStop: stopper("c"),
+			}),
+		)
+
+		assert.Equal(t, err, l.Start(context.Background()))
+		assert.NoError(t, l.Stop(context.Background()))
+		assert.Equal(t, []string{"a"}, stopped)
+	})
+}
+
+func TestLifecycleHookTimeouts(t *testing.T) {
+	t.Run("StartTimeoutNamesHook", func(t *testing.T) {
+		l := New(nil)
+		block := make(chan struct{})
+		defer close(block)
+
+		l.Append(Hook{
+			OnStart: func(context.Context) error {
+				<-block // ignores the context
+				return nil
+			},
+			OnStartTimeout: time.Millisecond,
+		})
+
+		err := l.Start(context.Background())
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded), "expected a deadline error, got %v", err)
+		assert.Contains(t, err.Error(), "OnStart hook added by go.uber.org/fx/internal/lifecycle.TestLifecycleHookTimeouts.func1()")
+		assert.Contains(t, err.Error(), "1ms timeout")
+	})
+
+	t.Run("StopTimeoutDoesntHaltChain", func(t *testing.T) {
+		l := New(nil)
+		var stopped bool
+
+		l.Append(Hook{
+			OnStop: func(context.Context) error {
+				stopped = true
+				return nil
+			},
+		})
+		l.Append(Hook{
+			OnStop: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			OnStopTimeout: time.Millisecond,
+		})
+
+		require.NoError(t, l.Start(context.Background()))
+		err := l.Stop(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "OnStop hook added by")
+		assert.True(t, stopped, "expected the first hook to be stopped")
+	})
+
+	t.Run("CallerDeadlineIsReportedAsIs", func(t *testing.T) {
+		l := New(nil)
+		l.Append(Hook{
+			OnStart: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			OnStartTimeout: time.Minute,
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+		assert.Equal(t, context.DeadlineExceeded, l.Start(ctx))
+	})
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// A PanicError is returned in place of a panic recovered from a function.
+type PanicError struct {
+	// Func is the name of the function that panicked, in the format used by
+	// fxreflect.Caller.
+	Func string
+
+	// Value is the value passed to panic.
+	Value interface{}
+
+	// Stack is the stack trace of the panicking goroutine.
+	Stack []byte
+}
+
+// NewPanicError builds a PanicError for the function named fn. It must be
+// called from the deferred function that recovered the panic so that the
+// stack trace includes the panicking frames.
+func NewPanicError(fn string, v interface{}) *PanicError {
+	return &PanicError{Func: fn, Value: v, Stack: debug.Stack()}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic in %s(): %v", e.Func, e.Value)
+}
+
+// Unwrap returns the panic value if it's an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// RecoverFromPanics makes the lifecycle turn panics in hooks and in functions
+// run by its workers into *PanicError errors, attributed to the function that
+// appended the hook or to the worker's function, respectively.
+func (l *Lifecycle) RecoverFromPanics() {
+	l.mu.Lock()
+	l.recoverPanics = true
+	l.mu.Unlock()
+}
+
+func (l *Lifecycle) recovering() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.recoverPanics
+}
+
+// recoverPanics wraps fn so that it returns a *PanicError attributed to
+// caller instead of panicking.
+func recoverPanics(caller string, fn func(context.Context) error) func(context.Context) error {
+	return func(ctx context.Context) (err error) {
+		defer func() {
+			if v := recover(); v != nil {
+				err = NewPanicError(caller, v)
+			}
+		}()
+		return fn(ctx)
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package lifecycle
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/multierr"
+)
+
+// hookDeps returns, for each hook, the indexes of the earlier hooks that it
+// depends on.
+func (l *Lifecycle) hookDeps(hooks []Hook) [][]int {
+	deps := make([][]int, len(hooks))
+	for i, hook := range hooks {
+		for j, dep := range hooks[:i] {
+			if hook.caller == dep.caller || l.dependsOn(hook.caller, dep.caller) {
+				deps[i] = append(deps[i], j)
+			}
+		}
+	}
+	return deps
+}
+
+// startParallel runs each OnStart hook in its own goroutine as soon as all
+// the hooks it depends on have started. Once a hook fails, hooks that haven't
+// begun starting are skipped.
+func (l *Lifecycle) startParallel(ctx context.Context, hooks []Hook) error {
+	var (
+		deps    = l.hookDeps(hooks)
+		done    = make([]chan struct{}, len(hooks))
+		started = make([]bool, len(hooks))
+		errs    = make([]error, len(hooks))
+
+		logger   = &syncLogger{Logger: l.logger}
+		failed   = make(chan struct{})
+		failOnce sync.Once
+		wg       sync.WaitGroup
+	)
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+
+	for i, hook := range hooks {
+		wg.Add(1)
+		go func(i int, hook Hook) {
+			defer wg.Done()
+			defer close(done[i])
+
+			for _, j := range deps[i] {
+				<-done[j]
+				if !started[j] {
+					return
+				}
+			}
+
+			select {
+			case <-failed:
+				return
+			default:
+			}
+
+			if err := l.run(ctx, _start, logger, hook); err != nil {
+				errs[i] = err
+				failOnce.Do(func() { close(failed) })
+				return
+			}
+			started[i] = true
+		}(i, hook)
+	}
+
+	wg.Wait()
+
+	l.mu.Lock()
+	l.started = started
+	l.mu.Unlock()
+	return multierr.Combine(errs...)
+}
+
+// stopParallel runs the OnStop hook of every started hook in its own
+// goroutine as soon as all the started hooks that depend on it have stopped.
+func (l *Lifecycle) stopParallel(ctx context.Context) error {
+	l.mu.Lock()
+	started := append([]bool(nil), l.started...)
+	l.mu.Unlock()
+
+	defer func() {
+		l.mu.Lock()
+		l.started = nil
+		l.mu.Unlock()
+	}()
+
+	var (
+		hooks      = l.snapshot()
+		deps       = l.hookDeps(hooks)
+		dependents = make([][]int, len(started))
+		done       = make([]chan struct{}, len(started))
+		errs       = make([]error, len(started))
+
+		logger = &syncLogger{Logger: l.logger}
+		wg     sync.WaitGroup
+	)
+	for i := range started {
+		done[i] = make(chan struct{})
+		if !started[i] {
+			continue
+		}
+		for _, j := range deps[i] {
+			dependents[j] = append(dependents[j], i)
+		}
+	}
+
+	for i := range started {
+		if !started[i] {
+			close(done[i])
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, hook Hook) {
+			defer wg.Done()
+			defer close(done[i])
+
+			for _, j := range dependents[i] {
+				<-done[j]
+			}
+
+			// Stopping, so no longer pending.
+			l.mu.Lock()
+			l.started[i] = false
+			l.mu.Unlock()
+
+			// For best-effort cleanup, keep going after errors.
+			errs[i] = l.run(ctx, _stop, logger, hook)
+		}(i, hooks[i])
+	}
+
+	wg.Wait()
+
+	// Report errors in the same order as a sequential Stop would.
+	for i, j := 0, len(errs)-1; i < j; i, j = i+1, j-1 {
+		errs[i], errs[j] = errs[j], errs[i]
+	}
+	return multierr.Combine(errs...)
+}
+
+// syncLogger serializes calls to Printf, since hooks' log lines may be
+// printed concurrently.
+type syncLogger struct {
+	Logger
+
+	mu sync.Mutex
+}
+
+func (l *syncLogger) Printf(format string, params ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.Logger.Printf(format, params...)
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package lifecycle
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/multierr"
+)
+
+// Reload calls the OnReload callbacks of the hooks that started, in order.
+// It keeps going after errors and returns them combined. Reload returns an
+// error without calling any callback if the lifecycle hasn't fully started.
+func (l *Lifecycle) Reload(ctx context.Context) error {
+	l.mu.Lock()
+	hooks := l.hooks[:l.numPostStarted:l.numPostStarted]
+	started := l.numPostStarted > 0 || len(l.hooks) == 0
+	l.mu.Unlock()
+
+	if !started {
+		return errors.New("lifecycle not started: Start must succeed before reloading it")
+	}
+
+	var errs []error
+	for _, hook := range hooks {
+		if err := l.run(ctx, _reload, l.logger, hook); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return multierr.Combine(errs...)
+}
+
+// Reloadable reports whether any of the hooks that started has an OnReload
+// callback.
+func (l *Lifecycle) Reloadable() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, hook := range l.hooks[:l.numPostStarted] {
+		if hook.OnReload != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package lifecycle
+
+import "time"
+
+// A Report records how long the lifecycle took to start or stop.
+type Report struct {
+	// Hooks lists the hook callbacks that ran, in the order in which they
+	// returned.
+	Hooks []HookTiming
+
+	// Phases lists the phases that ran, in order.
+	Phases []PhaseTiming
+
+	// Duration is the total time taken.
+	Duration time.Duration
+}
+
+// A HookTiming records how long a hook callback took.
+type HookTiming struct {
+	// Phase is the name of the callback, such as "OnStart".
+	Phase string
+
+	// Caller is the function that appended the hook, in the format used by
+	// fxreflect.Caller.
+	Caller string
+
+	Duration time.Duration
+}
+
+// A PhaseTiming records how long a phase took, such as running the OnStart
+// callbacks of all hooks.
+type PhaseTiming struct {
+	// Phase is the name of the callbacks run by the phase, such as
+	// "OnStart".
+	Phase string
+
+	Duration time.Duration
+}
+
+// StartReport returns the timings of the last call to Start. If Start is
+// running, the report is incomplete and its Duration is zero.
+func (l *Lifecycle) StartReport() Report {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.startReport.clone()
+}
+
+// StopReport returns the timings of the last call to Stop. If Stop is
+// running, the report is incomplete and its Duration is zero.
+func (l *Lifecycle) StopReport() Report {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stopReport.clone()
+}
+
+func (r Report) clone() Report {
+	r.Hooks = append([]HookTiming(nil), r.Hooks...)
+	r.Phases = append([]PhaseTiming(nil), r.Phases...)
+	return r
+}
+
+// beginReport resets *r and returns a function that records the total time
+// taken into it.
+func (l *Lifecycle) beginReport(r *Report) func() {
+	began := time.Now()
+
+	l.mu.Lock()
+	*r = Report{}
+	l.mu.Unlock()
+
+	return func() {
+		d := time.Since(began)
+
+		l.mu.Lock()
+		r.Duration = d
+		l.mu.Unlock()
+	}
+}
+
+// timePhase calls f, recording how long it took as phase p into *r.
+func (l *Lifecycle) timePhase(r *Report, p phase, f func()) {
+	began := time.Now()
+	f()
+	d := time.Since(began)
+
+	l.mu.Lock()
+	r.Phases = append(r.Phases, PhaseTiming{Phase: p.name, Duration: d})
+	l.mu.Unlock()
+}
+
+// recordHook records how long a hook's callback for phase p took.
+func (l *Lifecycle) recordHook(p phase, caller string, d time.Duration) {
+	t := HookTiming{Phase: p.name, Caller: caller, Duration: d}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	switch p.kind {
+	case HookStart:
+		l.startReport.Hooks = append(l.startReport.Hooks, t)
+	case HookStop:
+		l.stopReport.Hooks = append(l.stopReport.Hooks, t)
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package lifecycle
+
+import (
+	"fmt"
+	"time"
+)
+
+// RestartMode specifies when a worker is restarted after it returns.
+type RestartMode int
+
+const (
+	// RestartNever never restarts a worker. This is the default.
+	RestartNever RestartMode = iota
+
+	// RestartOnFailure restarts a worker that returned an error.
+	RestartOnFailure
+
+	// RestartAlways restarts a worker whenever it returns before being
+	// stopped.
+	RestartAlways
+)
+
+// Default backoff between restarts of a worker, if unspecified.
+const (
+	DefaultMinBackoff = 100 * time.Millisecond
+	DefaultMaxBackoff = 30 * time.Second
+)
+
+// RestartPolicy configures how a worker is supervised.
+type RestartPolicy struct {
+	Mode RestartMode
+
+	// The delay before a restart starts at MinBackoff and doubles with each
+	// restart within Window, up to MaxBackoff. Without a Window, it starts
+	// over once the worker runs for longer than MaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// If positive, the worker gives up once it has been restarted
+	// MaxRestarts times within Window. A zero Window counts all restarts.
+	MaxRestarts int
+	Window      time.Duration
+}
+
+// A WorkerOption configures the RestartPolicy of a worker.
+type WorkerOption func(*RestartPolicy)
+
+func (p RestartPolicy) shouldRestart(err error) bool {
+	switch p.Mode {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return err != nil
+	default:
+		return false
+	}
+}
+
+// restarts tracks the restarts of a worker that count against its policy.
+type restarts struct {
+	policy RestartPolicy
+
+	// Restarts within the policy's Window, if positive.
+	times []time.Time
+
+	// Without a Window, all the restarts so far, and those since the worker
+	// last ran for longer than MaxBackoff.
+	count  int
+	streak int
+
+	// When the worker was last restarted.
+	resumed time.Time
+}
+
+// next records a restart at the given time and returns how long to wait
+// before restarting, or an error if the policy is exhausted.
+func (r *restarts) next(now time.Time) (time.Duration, error) {
+	minBackoff, maxBackoff := r.policy.MinBackoff, r.policy.MaxBackoff
+	if minBackoff <= 0 {
+		minBackoff = DefaultMinBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultMaxBackoff
+	}
+
+	// Restarts counted against MaxRestarts, and those that back off.
+	var counted, backoffs int
+	if w := r.policy.Window; w > 0 {
+		recent := r.times[:0]
+		for _, t := range r.times {
+			if now.Sub(t) < w {
+				recent = append(recent, t)
+			}
+		}
+		r.times = recent
+		counted, backoffs = len(r.times), len(r.times)
+	} else {
+		if !r.resumed.IsZero() && now.Sub(r.resumed) > maxBackoff {
+			// The worker ran for a while: back off from scratch.
+			r.streak = 0
+		}
+		counted, backoffs = r.count, r.streak
+	}
+
+	if max := r.policy.MaxRestarts; max > 0 && counted >= max {
+		if w := r.policy.Window; w > 0 {
+			return 0, fmt.Errorf("gave up after %d restarts within %v", counted, w)
+		}
+		return 0, fmt.Errorf("gave up after %d restarts", counted)
+	}
+
+	backoff := minBackoff
+	for i := 0; i < backoffs && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+
+	if r.policy.Window > 0 {
+		r.times = append(r.times, now)
+	} else {
+		r.count++
+		r.streak++
+	}
+	r.resumed = now.Add(backoff)
+	return backoff, nil
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package lifecycle
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestartsBackoff(t *testing.T) {
+	r := restarts{policy: RestartPolicy{
+		MinBackoff: time.Second,
+		MaxBackoff: 5 * time.Second,
+	}}
+
+	now := time.Now()
+	for _, want := range []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second,
+	} {
+		backoff, err := r.next(now)
+		require.NoError(t, err)
+		assert.Equal(t, want, backoff)
+	}
+
+	t.Run("ResetsAfterLongRun", func(t *testing.T) {
+		r := restarts{policy: RestartPolicy{
+			MinBackoff: time.Second,
+			MaxBackoff: 5 * time.Second,
+		}}
+
+		now := time.Now()
+		for _, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+			backoff, err := r.next(now)
+			require.NoError(t, err)
+			assert.Equal(t, want, backoff)
+			// Fail right after restarting.
+			now = now.Add(backoff)
+		}
+
+		// The worker then runs for longer than MaxBackoff before failing.
+		backoff, err := r.next(now.Add(time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, time.Second, backoff, "backoff must start over after a long run")
+	})
+}
+
+func TestRestartsDefaultBackoff(t *testing.T) {
+	var r restarts
+	backoff, err := r.next(time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, DefaultMinBackoff, backoff)
+}
+
+func TestRestartsMaxRestarts(t *testing.T) {
+	t.Run("WithoutWindow", func(t *testing.T) {
+		r := restarts{policy: RestartPolicy{MaxRestarts: 2}}
+		now := time.Now()
+
+		for i := 0; i < 2; i++ {
+			_, err := r.next(now.Add(time.Duration(i) * time.Hour))
+			require.NoError(t, err)
+		}
+		_, err := r.next(now.Add(time.Hour))
+		assert.EqualError(t, err, "gave up after 2 restarts")
+	})
+
+	t.Run("WithinWindow", func(t *testing.T) {
+		r := restarts{policy: RestartPolicy{
+			MinBackoff:  time.Second,
+			MaxRestarts: 2,
+			Window:      time.Minute,
+		}}
+		now := time.Now()
+
+		_, err := r.next(now)
+		require.NoError(t, err)
+		_, err = r.next(now.Add(10 * time.Second))
+		require.NoError(t, err)
+		_, err = r.next(now.Add(20 * time.Second))
+		assert.EqualError(t, err, "gave up after 2 restarts within 1m0s")
+
+		// Once the first restart falls out of the window, the worker may be
+		// restarted again, and the backoff reflects the remaining restart.
+		backoff, err := r.next(now.Add(61 * time.Second))
+		require.NoError(t, err)
+		assert.Equal(t, 2*time.Second, backoff)
+	})
+}
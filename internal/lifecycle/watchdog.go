@@ -0,0 +1,206 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package lifecycle
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// A StuckHook describes a hook callback that's still running, typically
+// because the deadline for starting or stopping the lifecycle expired.
+type StuckHook struct {
+	// Phase is the name of the callback, such as "OnStart".
+	Phase string
+
+	// Caller is the function that appended the hook, in the format used by
+	// fxreflect.Caller.
+	Caller string
+
+	// Running is how long the callback has been running.
+	Running time.Duration
+
+	// Stack is the stack trace of the goroutine running the callback, in the
+	// format used by runtime.Stack.
+	Stack string
+}
+
+func (h StuckHook) String() string {
+	return fmt.Sprintf("%s hook added by %s() still running after %v",
+		h.Phase, h.Caller, h.Running.Round(time.Millisecond))
+}
+
+type runningHook struct {
+	phase  string
+	caller string
+	start  time.Time
+	goid   string
+}
+
+// track wraps fn so that StuckHooks reports it while it runs.
+func (l *Lifecycle) track(phase, caller string, fn func(context.Context) error) func(context.Context) error {
+	return func(ctx context.Context) error {
+		r := &runningHook{
+			phase:  phase,
+			caller: caller,
+			start:  time.Now(),
+			goid:   goroutineID(),
+		}
+
+		l.mu.Lock()
+		if l.running == nil {
+			l.running = make(map[*runningHook]struct{})
+		}
+		l.running[r] = struct{}{}
+		l.mu.Unlock()
+
+		defer func() {
+			l.mu.Lock()
+			delete(l.running, r)
+			l.mu.Unlock()
+		}()
+		return fn(ctx)
+	}
+}
+
+// StuckHooks reports the hook callbacks that are currently running, longest
+// running first, along with the stack trace of each.
+func (l *Lifecycle) StuckHooks() []StuckHook {
+	l.mu.Lock()
+	running := make([]*runningHook, 0, len(l.running))
+	for r := range l.running {
+		running = append(running, r)
+	}
+	l.mu.Unlock()
+
+	if len(running) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	stacks := goroutineStacks()
+	hooks := make([]StuckHook, len(running))
+	for i, r := range running {
+		hooks[i] = StuckHook{
+			Phase:   r.phase,
+			Caller:  r.caller,
+			Running: now.Sub(r.start),
+			Stack:   stacks[r.goid],
+		}
+	}
+
+	sort.Slice(hooks, func(i, j int) bool {
+		return hooks[i].Running > hooks[j].Running
+	})
+	return hooks
+}
+
+// A PendingHook describes a stop-side hook callback that Stop hasn't called
+// yet.
+type PendingHook struct {
+	// Phase is the name of the callback, such as "OnStop".
+	Phase string
+
+	// Caller is the function that appended the hook, in the format used by
+	// fxreflect.Caller.
+	Caller string
+}
+
+func (h PendingHook) String() string {
+	return fmt.Sprintf("%s hook added by %s()", h.Phase, h.Caller)
+}
+
+// PendingStops reports the stop-side hook callbacks that Stop has yet to
+// call, in the order in which it would call them. Callbacks that are running
+// are reported by StuckHooks instead.
+func (l *Lifecycle) PendingStops() []PendingHook {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var pending []PendingHook
+	add := func(p phase, hook Hook) {
+		if p.callback(hook) != nil {
+			pending = append(pending, PendingHook{Phase: p.name, Caller: hook.caller})
+		}
+	}
+
+	for i := l.numPostStarted - 1; i >= 0; i-- {
+		add(_preStop, l.hooks[i])
+	}
+	if l.dependsOn != nil {
+		for i := len(l.started) - 1; i >= 0; i-- {
+			if l.started[i] {
+				add(_stop, l.hooks[i])
+			}
+		}
+	} else {
+		for i := l.numStarted - 1; i >= 0; i-- {
+			add(_stop, l.hooks[i])
+		}
+	}
+	for i := l.numPreStarted - 1; i >= 0; i-- {
+		add(_postStop, l.hooks[i])
+	}
+	return pending
+}
+
+var _goroutinePrefix = []byte("goroutine ")
+
+// goroutineID returns the ID of the calling goroutine, as printed in stack
+// traces.
+func goroutineID() string {
+	var buf [64]byte
+	b := bytes.TrimPrefix(buf[:runtime.Stack(buf[:], false)], _goroutinePrefix)
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		return string(b[:i])
+	}
+	return ""
+}
+
+// goroutineStacks returns the stack traces of all goroutines, keyed by
+// goroutine ID.
+func goroutineStacks() map[string]string {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	stacks := make(map[string]string)
+	for _, stack := range bytes.Split(buf, []byte("\n\n")) {
+		id := bytes.TrimPrefix(stack, _goroutinePrefix)
+		if i := bytes.IndexByte(id, ' '); i > 0 {
+			if _, err := strconv.Atoi(string(id[:i])); err == nil {
+				stacks[string(id[:i])] = string(stack)
+			}
+		}
+	}
+	return stacks
+}
@@ -0,0 +1,181 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/fx/internal/fxreflect"
+)
+
+// Worker returns a hook that runs fn in its own goroutine from OnStart until
+// OnStop. The context passed to fn is canceled when OnStop runs, and OnStop
+// waits for fn to return.
+//
+// If fn returns before it's asked to stop, it's restarted as allowed by the
+// RestartPolicy built from opts. Otherwise, if fn failed or the policy is
+// exhausted, the failure is logged and reported to the function registered
+// with OnWorkerFailure. Worker may be called on a nil Lifecycle, in which
+// case the worker's failures are only reported by OnStop.
+func (l *Lifecycle) Worker(name string, fn func(context.Context) error, opts ...WorkerOption) Hook {
+	w := &worker{name: name, fn: fn, lc: l}
+	for _, opt := range opts {
+		opt(&w.policy)
+	}
+	return Hook{
+		OnStart: w.start,
+		OnStop:  w.stop,
+	}
+}
+
+// OnWorkerFailure registers a function that is called when a function run
+// by a worker returns an error before the lifecycle stops it. It receives the
+// name of the failed function, formatted like fxreflect.Caller, and the error.
+func (l *Lifecycle) OnWorkerFailure(f func(fn string, err error)) {
+	l.mu.Lock()
+	l.workerFailed = f
+	l.mu.Unlock()
+}
+
+func (l *Lifecycle) reportWorkerFailure(fn string, err error) {
+	l.mu.Lock()
+	f := l.workerFailed
+	l.mu.Unlock()
+
+	if f != nil {
+		f(fn, err)
+	}
+}
+
+type worker struct {
+	name   string
+	fn     func(context.Context) error
+	policy RestartPolicy
+	lc     *Lifecycle // nil for workers appended to other lifecycles
+
+	// The current run of the worker. OnStart and OnStop don't run
+	// concurrently, so this doesn't need synchronization.
+	run *workerRun
+}
+
+type workerRun struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// err is safe to read once done is closed.
+	err error
+}
+
+func (w *worker) start(context.Context) error {
+	// The start context expires once the application has started, so the
+	// worker's context is only canceled by stop.
+	ctx, cancel := context.WithCancel(context.Background())
+	run := &workerRun{cancel: cancel, done: make(chan struct{})}
+	w.run = run
+
+	go func() {
+		defer close(run.done)
+		run.err = w.loop(ctx)
+	}()
+	return nil
+}
+
+// loop runs the worker's function and returns the error that stop should
+// report.
+func (w *worker) loop(ctx context.Context) error {
+	fn := w.fn
+	if w.lc != nil && w.lc.recovering() {
+		fn = recoverPanics(strings.TrimSuffix(fxreflect.FuncName(fn), "()"), fn)
+	}
+
+	r := restarts{policy: w.policy}
+	for {
+		err := fn(ctx)
+		if ctx.Err() != nil {
+			// Asked to stop.
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+			return err
+		}
+
+		if !w.policy.shouldRestart(err) {
+			if err == nil {
+				w.logf("WORKER\t\t%q exited", w.name)
+				return nil
+			}
+			return w.fail(fmt.Errorf("worker %q failed: %w", w.name, err))
+		}
+
+		backoff, giveUp := r.next(time.Now())
+		if giveUp != nil {
+			if err == nil {
+				return w.fail(fmt.Errorf("worker %q %v", w.name, giveUp))
+			}
+			return w.fail(fmt.Errorf("worker %q %v: %w", w.name, giveUp, err))
+		}
+
+		if err == nil {
+			w.logf("RESTART\t\t%q in %v: exited", w.name, backoff)
+		} else {
+			w.logf("RESTART\t\t%q in %v: %v", w.name, backoff, err)
+		}
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil
+		case <-t.C:
+		}
+	}
+}
+
+// fail logs and reports a worker's failure, and returns it.
+func (w *worker) fail(err error) error {
+	w.logf("ERROR\t\t%v", err)
+	if w.lc != nil {
+		w.lc.reportWorkerFailure(strings.TrimSuffix(fxreflect.FuncName(w.fn), "()"), err)
+	}
+	return err
+}
+
+func (w *worker) logf(format string, args ...interface{}) {
+	if w.lc != nil {
+		w.lc.logger.Printf(format, args...)
+	}
+}
+
+func (w *worker) stop(ctx context.Context) error {
+	run := w.run
+	run.cancel()
+
+	select {
+	case <-run.done:
+		return run.err
+	case <-ctx.Done():
+		return fmt.Errorf("worker %q didn't stop: %w", w.name, ctx.Err())
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorker(t *testing.T) {
+	t.Run("RunsUntilStopped", func(t *testing.T) {
+		l := New(nil)
+		running := make(chan struct{})
+		l.Append(l.Worker("loop", func(ctx context.Context) error {
+			close(running)
+			<-ctx.Done()
+			return ctx.Err()
+		}))
+
+		require.NoError(t, l.Start(context.Background()))
+		<-running
+		assert.NoError(t, l.Stop(context.Background()))
+	})
+
+	t.Run("ReportsFailure", func(t *testing.T) {
+		l := New(nil)
+		failures := make(chan error, 1)
+		var failed string
+		l.OnWorkerFailure(func(fn string, err error) {
+			failed = fn
+			failures <- err
+		})
+
+		giveUp := errors.New("great sadness")
+		l.Append(l.Worker("loop", func(context.Context) error { return giveUp }))
+
+		require.NoError(t, l.Start(context.Background()))
+		err := <-failures
+		assert.Contains(t, failed, "TestWorker.func")
+		assert.True(t, errors.Is(err, giveUp), "expected the worker's error, got %v", err)
+		assert.Contains(t, err.Error(), `worker "loop" failed`)
+		assert.Equal(t, err, l.Stop(context.Background()))
+	})
+
+	t.Run("WithoutLifecycle", func(t *testing.T) {
+		var l *Lifecycle
+		giveUp := errors.New("great sadness")
+		h := l.Worker("loop", func(context.Context) error { return giveUp })
+
+		require.NoError(t, h.OnStart(context.Background()))
+		err := h.OnStop(context.Background())
+		assert.True(t, errors.Is(err, giveUp), "expected the worker's error, got %v", err)
+	})
+
+	t.Run("RecoversFromPanics", func(t *testing.T) {
+		l := New(nil)
+		l.RecoverFromPanics()
+		failures := make(chan error, 1)
+		l.OnWorkerFailure(func(_ string, err error) { failures <- err })
+
+		l.Append(l.Worker("loop", func(context.Context) error { panic("great sadness") }))
+
+		require.NoError(t, l.Start(context.Background()))
+		err := <-failures
+		var pe *PanicError
+		require.True(t, errors.As(err, &pe), "expected a PanicError, got %v", err)
+		assert.Equal(t, "great sadness", pe.Value)
+		assert.Equal(t, err, l.Stop(context.Background()))
+	})
+
+	t.Run("ReportsErrorAfterStop", func(t *testing.T) {
+		l := New(nil)
+		l.OnWorkerFailure(func(_ string, err error) {
+			t.Errorf("unexpected worker failure: %v", err)
+		})
+
+		flushErr := errors.New("flush failed")
+		l.Append(l.Worker("loop", func(ctx context.Context) error {
+			<-ctx.Done()
+			return flushErr
+		}))
+
+		require.NoError(t, l.Start(context.Background()))
+		assert.Equal(t, flushErr, l.Stop(context.Background()))
+	})
+
+	t.Run("StopTimeout", func(t *testing.T) {
+		l := New(nil)
+		block := make(chan struct{})
+		defer close(block)
+		l.Append(l.Worker("stubborn", func(context.Context) error {
+			<-block
+			return nil
+		}))
+
+		require.NoError(t, l.Start(context.Background()))
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+
+		err := l.Stop(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `worker "stubborn" didn't stop`)
+	})
+}
+
+func TestWorkerRestarts(t *testing.T) {
+	fast := func(p *RestartPolicy) {
+		p.MinBackoff = time.Millisecond
+		p.MaxBackoff = time.Millisecond
+	}
+
+	t.Run("OnFailure", func(t *testing.T) {
+		l := New(nil)
+		l.OnWorkerFailure(func(_ string, err error) {
+			t.Errorf("unexpected worker failure: %v", err)
+		})
+
+		var runs int
+		running := make(chan struct{})
+		l.Append(l.Worker("flaky", func(ctx context.Context) error {
+			runs++
+			if runs < 3 {
+				return errors.New("great sadness")
+			}
+			close(running)
+			<-ctx.Done()
+			return nil
+		}, fast, func(p *RestartPolicy) { p.Mode = RestartOnFailure }))
+
+		require.NoError(t, l.Start(context.Background()))
+		<-running
+		assert.NoError(t, l.Stop(context.Background()))
+		assert.Equal(t, 3, runs)
+	})
+
+	t.Run("OnFailureDoesntRestartSuccess", func(t *testing.T) {
+		l := New(nil)
+		exited := make(chan struct{})
+		var runs int
+		l.Append(l.Worker("once", func(context.Context) error {
+			runs++
+			close(exited)
+			return nil
+		}, fast, func(p *RestartPolicy) { p.Mode = RestartOnFailure }))
+
+		require.NoError(t, l.Start(context.Background()))
+		<-exited
+		assert.NoError(t, l.Stop(context.Background()))
+		assert.Equal(t, 1, runs)
+	})
+
+	t.Run("GivesUp", func(t *testing.T) {
+		l := New(nil)
+		failures := make(chan error, 1)
+		l.OnWorkerFailure(func(_ string, err error) { failures <- err })
+
+		var runs int
+		giveUp := errors.New("great sadness")
+		l.Append(l.Worker("flaky", func(context.Context) error {
+			runs++
+			return giveUp
+		}, fast, func(p *RestartPolicy) {
+			p.Mode = RestartAlways
+			p.MaxRestarts = 2
+		}))
+
+		require.NoError(t, l.Start(context.Background()))
+		err := <-failures
+		assert.EqualError(t, err, `worker "flaky" gave up after 2 restarts: great sadness`)
+		assert.True(t, errors.Is(err, giveUp), "expected the worker's last error")
+		assert.Equal(t, 3, runs)
+		assert.Equal(t, err, l.Stop(context.Background()))
+	})
+
+	t.Run("StopDuringBackoff", func(t *testing.T) {
+		l := New(nil)
+		failed := make(chan struct{})
+		l.Append(l.Worker("slow", func(context.Context) error {
+			close(failed)
+			return errors.New("great sadness")
+		}, func(p *RestartPolicy) {
+			p.Mode = RestartOnFailure
+			p.MinBackoff = time.Hour
+		}))
+
+		require.NoError(t, l.Start(context.Background()))
+		<-failed
+		assert.NoError(t, l.Stop(context.Background()))
+	})
+}
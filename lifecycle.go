@@ -22,6 +22,7 @@ package fx
 
 import (
 	"context"
+	"time"
 
 	"go.uber.org/fx/internal/lifecycle"
 )
@@ -30,6 +31,7 @@ import (
 // application start and stop. See the documentation for App for details on Fx
 // applications' initialization, startup, and shutdown logic.
 type Lifecycle interface {
+	// Append registers a Hook.
 	Append(Hook)
 }
 
@@ -37,16 +39,83 @@ type Lifecycle interface {
 // If a Hook's OnStart callback isn't executed (because a previous OnStart
 // failure short-circuited application startup), its OnStop callback won't be
 // executed.
+//
+// OnStartTimeout and OnStopTimeout optionally bound the time the
+// corresponding callback may take, in addition to the application-wide
+// StartTimeout and StopTimeout. A callback exceeding its own timeout fails
+// with an error naming the function that appended the hook.
+//
+// The phase callbacks, all optional, run around the OnStart and OnStop
+// callbacks of the whole application. On start, every hook's OnPreStart runs
+// before any OnStart, and every OnPostStart runs once all OnStart callbacks
+// have succeeded. On stop, every OnPreStop runs before any OnStop, and every
+// OnPostStop runs after all OnStop callbacks. For example, a health check
+// can report readiness in OnPostStart, after all servers are listening, and
+// withdraw it in OnPreStop, before any server closes its listener.
+//
+// Each phase runs in order on start and in reverse order on stop. A stop-side
+// callback runs only if its start-side counterpart ran: OnPreStop pairs with
+// OnPostStart, and OnPostStop pairs with OnPreStart. Start-side phase
+// callbacks share OnStartTimeout, and stop-side ones share OnStopTimeout.
+//
+// OnReload, also optional, is called while the application is running when
+// it's asked to reload, for example to pick up renewed TLS certificates. See
+// Reloader for details.
 type Hook struct {
 	OnStart func(context.Context) error
 	OnStop  func(context.Context) error
+
+	OnStartTimeout time.Duration
+	OnStopTimeout  time.Duration
+
+	OnPreStart  func(context.Context) error
+	OnPostStart func(context.Context) error
+	OnPreStop   func(context.Context) error
+	OnPostStop  func(context.Context) error
+
+	OnReload func(context.Context) error
 }
 
+// A HookError reports the failure of a Lifecycle hook callback. The errors
+// returned by App.Start and App.Stop wrap a HookError for each callback that
+// failed, so errors.As finds the first of them; use multierr.Errors to get
+// all of them. A HookError's message is that of the callback's error,
+// prefixed with the callback's name and the function that appended the hook.
+type HookError = lifecycle.HookError
+
+// HookPhase identifies what the application was doing when a hook failed.
+type HookPhase = lifecycle.HookPhase
+
+// Phases reported by HookError.
+const (
+	// HookStart is used for failures of OnStart, OnPreStart, and OnPostStart
+	// callbacks.
+	HookStart = lifecycle.HookStart
+
+	// HookStop is used for failures of OnStop, OnPreStop, and OnPostStop
+	// callbacks.
+	HookStop = lifecycle.HookStop
+
+	// HookRollback is used for failures of stop-side callbacks while
+	// stopping the hooks that started before Start failed.
+	HookRollback = lifecycle.HookRollback
+
+	// HookReload is used for failures of OnReload callbacks.
+	HookReload = lifecycle.HookReload
+)
+
 type lifecycleWrapper struct{ *lifecycle.Lifecycle }
 
 func (l *lifecycleWrapper) Append(h Hook) {
 	l.Lifecycle.Append(lifecycle.Hook{
-		OnStart: h.OnStart,
-		OnStop:  h.OnStop,
+		OnStart:        h.OnStart,
+		OnStop:         h.OnStop,
+		OnStartTimeout: h.OnStartTimeout,
+		OnStopTimeout:  h.OnStopTimeout,
+		OnPreStart:     h.OnPreStart,
+		OnPostStart:    h.OnPostStart,
+		OnPreStop:      h.OnPreStop,
+		OnPostStop:     h.OnPostStop,
+		OnReload:       h.OnReload,
 	})
 }
@@ -0,0 +1,84 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fx
+
+import (
+	"errors"
+
+	"go.uber.org/fx/internal/fxreflect"
+	"go.uber.org/fx/internal/lifecycle"
+)
+
+// RecoverFromPanics makes the application recover from panics in
+// constructors, invoked functions, Lifecycle hooks, and functions run with Go,
+// and report them as *PanicError errors instead of crashing.
+//
+// Panics in constructors and invoked functions fail New, so Err returns them
+// and ErrorHook handlers see them. Panics in Lifecycle hooks fail Start or
+// Stop like any other hook error: if an OnStart hook panics, the hooks that
+// already started are stopped. ErrorHook handlers see these panics too.
+func RecoverFromPanics() Option {
+	return optionFunc(func(app *App) {
+		app.recoverPanics = true
+	})
+}
+
+// A PanicError is returned in place of a panic recovered by an application
+// using the RecoverFromPanics option. Use errors.As to retrieve it from the
+// errors returned by the application.
+//
+// Its Func field names the innermost provided constructor or invoked function
+// that was running when the panic happened, or the function that appended the
+// Lifecycle hook that panicked.
+type PanicError = lifecycle.PanicError
+
+// invoke calls fn with the container, recovering from panics in fn and in the
+// constructors it requires if the application is set up to.
+func (app *App) invoke(fn interface{}) (err error) {
+	if app.recoverPanics {
+		defer func() {
+			if v := recover(); v != nil {
+				err = lifecycle.NewPanicError(app.panickingFunc(fn), v)
+			}
+		}()
+	}
+	return app.container.Invoke(fn)
+}
+
+// panickingFunc returns the name of the innermost provided constructor or
+// invoked function on the panicking goroutine's stack, falling back to fn.
+func (app *App) panickingFunc(fn interface{}) string {
+	for _, name := range fxreflect.CallStack() {
+		if app.deps.known(name) {
+			return name
+		}
+	}
+	return funcPath(fn)
+}
+
+// handlePanic passes err to the ErrorHook handlers if it was caused by a
+// recovered panic.
+func (app *App) handlePanic(err error) {
+	var pe *PanicError
+	if errors.As(err, &pe) {
+		errorHandlerList(app.errorHooks).HandleError(err)
+	}
+}
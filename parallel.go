@@ -0,0 +1,243 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fx
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"go.uber.org/dig"
+
+	"go.uber.org/fx/internal/fxreflect"
+)
+
+// ParallelLifecycle starts and stops the application's Lifecycle hooks
+// concurrently wherever the dependency graph allows it.
+//
+// Hooks appended by a constructor wait for the hooks appended by the
+// constructors it (transitively) depends on, so each constructor's OnStart
+// hooks still run after its dependencies' OnStart hooks, and its OnStop hooks
+// still run before its dependencies' OnStop hooks. Hooks appended by the same
+// function always run in order. Hooks appended from anywhere other than a
+// provided constructor or an invoked function (for example, from a helper
+// called by a constructor) wait for every hook appended before them.
+//
+// If an OnStart hook fails, hooks that haven't started yet are skipped, and
+// only hooks whose OnStart completed are stopped during rollback.
+func ParallelLifecycle() Option {
+	return optionFunc(func(app *App) {
+		app.parallel = true
+	})
+}
+
+// depKey identifies a value in the container, mirroring dig's keys.
+type depKey struct {
+	t     reflect.Type
+	name  string
+	group string
+}
+
+func (k depKey) String() string {
+	switch {
+	case k.name != "":
+		return k.t.String() + ":" + k.name
+	case k.group != "":
+		return k.t.String() + "[group:" + k.group + "]"
+	}
+	return k.t.String()
+}
+
+// depGraph records which values each provided constructor and invoked
+// function consumes and produces, keyed by function name. It lets the
+// lifecycle decide whether hooks appended by two functions may run
+// concurrently.
+type depGraph struct {
+	mu        sync.Mutex
+	params    map[string][]depKey
+	providers map[depKey][]string
+	memo      map[[2]string]bool
+}
+
+func newDepGraph() *depGraph {
+	return &depGraph{
+		params:    make(map[string][]depKey),
+		providers: make(map[depKey][]string),
+		memo:      make(map[[2]string]bool),
+	}
+}
+
+// addProvide records a constructor and the values it produces. If name or
+// group are non-empty, they apply to all values produced by the constructor,
+// as with Annotated, and so does as, the interfaces they're also provided as.
+func (g *depGraph) addProvide(ctor interface{}, name, group string, as ...reflect.Type) {
+	g.addProvideFunc(funcPath(ctor), reflect.TypeOf(ctor), name, group, as...)
+}
+
+// addProvideFunc is like addProvide, for a constructor named fname of type
+// ft.
+func (g *depGraph) addProvideFunc(fname string, ft reflect.Type, name, group string, as ...reflect.Type) {
+	if ft == nil || ft.Kind() != reflect.Func {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.addParams(fname, ft)
+	for i := 0; i < ft.NumOut(); i++ {
+		walkResultKeys(ft.Out(i), name, group, func(k depKey) {
+			g.providers[k] = append(g.providers[k], fname)
+		})
+	}
+	for _, t := range as {
+		k := depKey{t: t, name: name}
+		g.providers[k] = append(g.providers[k], fname)
+	}
+	g.memo = make(map[[2]string]bool)
+}
+
+// addInvoke records an invoked function and the values it consumes.
+func (g *depGraph) addInvoke(fn interface{}) {
+	ft := reflect.TypeOf(fn)
+	if ft == nil || ft.Kind() != reflect.Func {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.addParams(funcPath(fn), ft)
+	g.memo = make(map[[2]string]bool)
+}
+
+func (g *depGraph) addParams(fname string, ft reflect.Type) {
+	if _, ok := g.params[fname]; !ok {
+		// Functions without parameters are still known.
+		g.params[fname] = nil
+	}
+	for i := 0; i < ft.NumIn(); i++ {
+		walkParamKeys(ft.In(i), "", "", func(k depKey) {
+			g.params[fname] = append(g.params[fname], k)
+		})
+	}
+}
+
+// known reports whether fname was recorded as a provided constructor or an
+// invoked function.
+func (g *depGraph) known(fname string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	_, ok := g.params[fname]
+	return ok
+}
+
+// dependsOn reports whether the function named caller transitively consumes
+// values produced by the function named dep. It conservatively returns true
+// if either function is unknown.
+func (g *depGraph) dependsOn(caller, dep string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, ok := g.params[caller]; !ok {
+		return true
+	}
+	if _, ok := g.params[dep]; !ok {
+		return true
+	}
+
+	memoKey := [2]string{caller, dep}
+	if v, ok := g.memo[memoKey]; ok {
+		return v
+	}
+
+	visited := map[string]struct{}{caller: {}}
+	queue := []string{caller}
+	found := false
+	for len(queue) > 0 && !found {
+		fname := queue[0]
+		queue = queue[1:]
+		for _, k := range g.params[fname] {
+			for _, p := range g.providers[k] {
+				if p == dep {
+					found = true
+				}
+				if _, ok := visited[p]; !ok {
+					visited[p] = struct{}{}
+					queue = append(queue, p)
+				}
+			}
+		}
+	}
+
+	g.memo[memoKey] = found
+	return found
+}
+
+func walkParamKeys(t reflect.Type, name, group string, f func(depKey)) {
+	if !dig.IsIn(t) {
+		if group != "" && t.Kind() == reflect.Slice {
+			t = t.Elem()
+		}
+		f(depKey{t: t, name: name, group: group})
+		return
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" || field.Type == _typeOfIn || field.Type == _typeOfDigIn {
+			continue
+		}
+		walkParamKeys(field.Type, field.Tag.Get("name"), field.Tag.Get("group"), f)
+	}
+}
+
+func walkResultKeys(t reflect.Type, name, group string, f func(depKey)) {
+	if t == _typeOfError {
+		return
+	}
+	if !dig.IsOut(t) {
+		f(depKey{t: t, name: name, group: group})
+		return
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" || field.Type == _typeOfOut || field.Type == _typeOfDigOut {
+			continue
+		}
+		walkResultKeys(field.Type, field.Tag.Get("name"), field.Tag.Get("group"), f)
+	}
+}
+
+var (
+	_typeOfError  = reflect.TypeOf((*error)(nil)).Elem()
+	_typeOfOut    = reflect.TypeOf(Out{})
+	_typeOfDigIn  = reflect.TypeOf(dig.In{})
+	_typeOfDigOut = reflect.TypeOf(dig.Out{})
+)
+
+// funcPath returns the name of a function in the format used by
+// fxreflect.Caller.
+func funcPath(fn interface{}) string {
+	return strings.TrimSuffix(fxreflect.FuncName(fn), "()")
+}
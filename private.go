@@ -0,0 +1,122 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fx
+
+import (
+	"fmt"
+	"reflect"
+
+	"go.uber.org/fx/internal/fxreflect"
+)
+
+// Private makes the constructors passed along with it to Provide private to
+// the enclosing Module: the values they produce are only available to the
+// constructors, decorators, and invoked functions of that module and of the
+// modules nested in it. For example,
+//
+//	var Module = fx.Module("kafka",
+//		fx.Provide(fx.Private, newConnPool),
+//		fx.Provide(NewClient), // may use the pool
+//	)
+//
+// Outside of the module, depending on a private value fails, with an error
+// naming the module. Private has no effect outside of a Module.
+var Private = privateOption{}
+
+// privateOption is the type of Private. It isn't an Option; it's passed to
+// Provide among constructors.
+type privateOption struct{}
+
+func (privateOption) String() string {
+	return "fx.Private"
+}
+
+// moduleInvoke is a function passed to Invoke inside a Module, which is run
+// by the module's parent.
+type moduleInvoke struct {
+	fn     interface{}
+	module *App
+}
+
+// addPrivate records the values produced by ctor as private to app.
+func (app *App) addPrivate(ctor interface{}) {
+	if app.parent == nil {
+		return
+	}
+	if app.private == nil {
+		app.private = make(map[depKey]struct{})
+	}
+	for _, k := range resultKeys(ctor) {
+		app.private[k] = struct{}{}
+	}
+}
+
+// privateOwner returns the module to which k is private, if any.
+func (app *App) privateOwner(k depKey) *App {
+	if _, ok := app.private[k]; ok {
+		return app
+	}
+	for _, ca := range app.children {
+		if owner := ca.privateOwner(k); owner != nil {
+			return owner
+		}
+	}
+	return nil
+}
+
+// checkPrivate returns an error if fn, a constructor, decorator, or invoked
+// function of app, depends on values that are private to a module app isn't
+// part of.
+func (app *App) checkPrivate(fn interface{}) error {
+	return app.checkPrivateParams(fxreflect.FuncName(fn), reflect.TypeOf(fn))
+}
+
+// checkPrivateParams is like checkPrivate, for a function named fname of
+// type ft.
+func (app *App) checkPrivateParams(fname string, ft reflect.Type) error {
+	if ft == nil || ft.Kind() != reflect.Func {
+		return nil
+	}
+
+	root := app.root()
+	var err error
+	for i := 0; i < ft.NumIn() && err == nil; i++ {
+		walkParamKeys(ft.In(i), "", "", func(k depKey) {
+			owner := root.privateOwner(k)
+			if err != nil || owner == nil || app.within(owner) {
+				return
+			}
+			err = fmt.Errorf("%s depends on %v, which is private to module %q",
+				fname, k, owner.module)
+		})
+	}
+	return err
+}
+
+// within reports whether app is module m or a module nested in it.
+func (app *App) within(m *App) bool {
+	for ; app != nil; app = app.parent {
+		if app == m {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fx_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+func TestPrivate(t *testing.T) {
+	type pool struct{}
+	type client struct{ pool *pool }
+	newPool := func() *pool { return &pool{} }
+	newClient := func(p *pool) *client { return &client{pool: p} }
+	module := fx.Module("kafka",
+		fx.Provide(fx.Private, newPool),
+		fx.Provide(newClient),
+	)
+
+	t.Run("UsedInsideModule", func(t *testing.T) {
+		var (
+			c      *client
+			nested *pool
+		)
+		app := fxtest.New(t,
+			fx.Module("kafka",
+				fx.Provide(fx.Private, newPool),
+				fx.Provide(newClient),
+				fx.Module("nested", fx.Invoke(func(p *pool) { nested = p })),
+			),
+			fx.Populate(&c),
+		)
+		defer app.RequireStart().RequireStop()
+		assert.NotNil(t, c.pool)
+		assert.Equal(t, c.pool, nested, "nested modules must see their parent's private values")
+	})
+
+	t.Run("InvokedOutsideModule", func(t *testing.T) {
+		app := fx.New(fx.NopLogger, module, fx.Invoke(func(*pool) {}))
+		err := app.Err()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `depends on *fx_test.pool, which is private to module "kafka"`)
+	})
+
+	t.Run("InvokedInsideModule", func(t *testing.T) {
+		app := fx.New(fx.NopLogger, fx.Module("kafka",
+			fx.Provide(fx.Private, newPool),
+			fx.Invoke(func(*pool) {}),
+		))
+		assert.NoError(t, app.Err())
+	})
+
+	t.Run("ProvidedOutsideModule", func(t *testing.T) {
+		type user struct{}
+		app := fx.New(fx.NopLogger,
+			module,
+			fx.Module("other", fx.Provide(func(*pool) *user { return nil })),
+		)
+		err := app.Err()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `which is private to module "kafka"`)
+	})
+
+	t.Run("DecoratedOutsideModule", func(t *testing.T) {
+		app := fx.New(fx.NopLogger,
+			module,
+			fx.Decorate(func(p *pool) *pool { return p }),
+		)
+		err := app.Err()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `depends on *fx_test.pool, which is private to module "kafka"`)
+	})
+
+	t.Run("DecoratedInsideModule", func(t *testing.T) {
+		var c *client
+		app := fxtest.New(t,
+			fx.Module("kafka",
+				fx.Provide(fx.Private, newPool),
+				fx.Provide(newClient),
+				fx.Decorate(func(p *pool) *pool { return p }),
+			),
+			fx.Populate(&c),
+		)
+		defer app.RequireStart().RequireStop()
+		assert.NotNil(t, c.pool)
+	})
+
+	t.Run("String", func(t *testing.T) {
+		assert.Equal(t, "fx.Private", fx.Private.String())
+	})
+}
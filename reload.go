@@ -0,0 +1,145 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fx
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+)
+
+// A Reloader asks the application to reload, calling the OnReload callbacks
+// of its Lifecycle hooks in order. Components use it to pick up changes to
+// their configuration or to renewed TLS certificates without restarting. The
+// Reloader is provided to all Fx applications.
+//
+// Reload keeps going if callbacks fail, and returns their errors combined,
+// each wrapped in a *HookError. Failures are also logged and passed to the
+// ErrorHook handlers, but don't stop the application. Reload only works
+// while the application is running; otherwise it returns a *StateError.
+// Concurrent reloads run one at a time: a reload waits for the previous one
+// to finish, even if the latter timed out, and fails if its context expires
+// first.
+//
+// Applications using Run also reload when they receive one of their reload
+// signals, SIGHUP by default (see ReloadSignals), as long as one of their
+// Lifecycle hooks has an OnReload callback.
+type Reloader interface {
+	Reload(context.Context) error
+}
+
+// ReloadSignals sets the OS signals that make an application using Run
+// reload, by default SIGHUP. Run only listens for them once the application
+// started with OnReload callbacks, leaving their default handling alone
+// otherwise, and ignores those that are also shutdown signals (see
+// ShutdownSignals). Without any signals, Run doesn't listen for reload
+// signals. Each reload is bounded by the application's StartTimeout.
+func ReloadSignals(signals ...os.Signal) Option {
+	return optionFunc(func(app *App) {
+		app.reloadSignals = signals
+	})
+}
+
+// Reload calls the OnReload callbacks of the application's Lifecycle hooks.
+// See the Reloader documentation for details.
+func (app *App) Reload(ctx context.Context) error {
+	if s := app.State(); s != StateRunning {
+		return &StateError{Op: "reload", State: s}
+	}
+
+	reload := func(ctx context.Context) error {
+		// Release the token once the callbacks return, not when
+		// withTimeout gives up on them.
+		defer func() { <-app.reloading }()
+		return app.lifecycle.Reload(ctx)
+	}
+
+	var err error
+	select {
+	case app.reloading <- struct{}{}:
+		err = app.withTimeout(ctx, reload, nil)
+	case <-ctx.Done():
+		err = fmt.Errorf("waiting for the previous reload: %w", ctx.Err())
+	}
+	if err != nil {
+		app.logger.Printf("ERROR\t\tReload failed: %v", err)
+		errorHandlerList(app.errorHooks).HandleError(err)
+	}
+	return err
+}
+
+func (app *App) reloader() Reloader {
+	return app
+}
+
+// handleReloadSignals reloads the application whenever it receives one of its
+// reload signals, until the returned function is called.
+func (app *App) handleReloadSignals() (stop func()) {
+	signals := app.activeReloadSignals()
+	if len(signals) == 0 {
+		return func() {}
+	}
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, signals...)
+	go func() {
+		for sig := range c {
+			app.logger.PrintSignal(sig)
+
+			ctx, cancel := context.WithTimeout(context.Background(), app.StartTimeout())
+			// Failures are logged and reported by Reload.
+			_ = app.Reload(ctx)
+			cancel()
+		}
+	}()
+
+	return func() {
+		signal.Stop(c)
+		close(c)
+	}
+}
+
+// activeReloadSignals returns the reload signals that Run listens for: none
+// unless a started hook has an OnReload callback, and never those that also
+// shut the application down.
+func (app *App) activeReloadSignals() []os.Signal {
+	if !app.lifecycle.Reloadable() {
+		return nil
+	}
+
+	var signals []os.Signal
+	for _, sig := range app.reloadSignals {
+		if !containsSignal(app.signals, sig) {
+			signals = append(signals, sig)
+		}
+	}
+	return signals
+}
+
+func containsSignal(signals []os.Signal, sig os.Signal) bool {
+	for _, s := range signals {
+		if s == sig {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,245 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fx
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go.uber.org/fx/internal/fxreflect"
+)
+
+// Replace provides values in place of those produced by the application's
+// constructors, typically to swap a real dependency for a fake in tests:
+//
+//	fx.New(
+//		kafka.Module,
+//		fx.Replace(fakeClient), // a *kafka.Client
+//		...
+//	)
+//
+// Like with Supply, values are provided with their concrete type, and may be
+// wrapped in an Annotated to replace a named value or a value group. The
+// constructors that produce the replaced values aren't provided at all, so
+// they must not produce any other value. A replaced value group only holds
+// the replacement values.
+//
+// Used inside a Module, Replace only replaces constructors provided to that
+// module and the modules nested in it. It fails if no constructor in scope
+// produces the replaced value.
+func Replace(values ...interface{}) Option {
+	caller := fxreflect.Caller()
+	targets := make([]interface{}, len(values))
+	for i, v := range values {
+		targets[i] = supplied{value: v, op: "fx.Replace", caller: caller}
+	}
+	return replaceOption{name: "fx.Replace", targets: targets}
+}
+
+// ReplaceProvide is like Replace, but it replaces the values produced by the
+// application's constructors with those produced by the given constructors,
+// which may be wrapped in an Annotated.
+func ReplaceProvide(constructors ...interface{}) Option {
+	return replaceOption{name: "fx.ReplaceProvide", targets: constructors}
+}
+
+type replaceOption struct {
+	name    string
+	targets []interface{}
+}
+
+func (o replaceOption) apply(app *App) {
+	for _, t := range o.targets {
+		app.replaces = append(app.replaces, &replacement{target: t, keys: resultKeys(t)})
+	}
+}
+
+func (o replaceOption) String() string {
+	items := make([]string, len(o.targets))
+	for i, t := range o.targets {
+		if s, ok := t.(supplied); ok {
+			t = s.value
+		}
+		if a, ok := t.(Annotated); ok {
+			t = a.Target
+		}
+		if reflect.TypeOf(t) != nil && reflect.TypeOf(t).Kind() == reflect.Func {
+			items[i] = fxreflect.FuncName(t)
+		} else {
+			items[i] = fmt.Sprint(reflect.TypeOf(t))
+		}
+	}
+	return fmt.Sprintf("%s(%s)", o.name, strings.Join(items, ", "))
+}
+
+// A replacement is a value or a constructor passed to Replace or
+// ReplaceProvide.
+type replacement struct {
+	target interface{}
+	keys   []depKey
+
+	// used reports whether a constructor was replaced.
+	used bool
+}
+
+// resultKeys returns the keys of the values produced by a constructor or a
+// supplied value, possibly Annotated.
+func resultKeys(target interface{}) []depKey {
+	s, isValue := target.(supplied)
+	if isValue {
+		target = s.value
+	}
+	var (
+		name, group string
+		as          []interface{}
+	)
+	if a, ok := target.(Annotated); ok {
+		target, name, group, as = a.Target, a.Name, a.Group, a.As
+		if ctor, err := a.build(); err == nil && !isValue {
+			target = ctor
+		}
+	}
+	var keys []depKey
+	for _, i := range as {
+		if t := reflect.TypeOf(i); t != nil && t.Kind() == reflect.Ptr {
+			keys = append(keys, depKey{t: t.Elem(), name: name})
+		}
+	}
+
+	t := reflect.TypeOf(target)
+	switch {
+	case t == nil:
+		return nil
+	case isValue:
+		return append(keys, depKey{t: t, name: name, group: group})
+	case t.Kind() != reflect.Func:
+		return keys
+	}
+
+	for i := 0; i < t.NumOut(); i++ {
+		walkResultKeys(t.Out(i), name, group, func(k depKey) {
+			keys = append(keys, k)
+		})
+	}
+	return keys
+}
+
+// provideReplacement provides a value or constructor passed to Replace or
+// ReplaceProvide.
+func (app *App) provideReplacement(r *replacement) {
+	if s, ok := r.target.(supplied); ok {
+		app.supply(s)
+		return
+	}
+
+	a, ok := r.target.(Annotated)
+	if !ok {
+		a = Annotated{Target: r.target}
+	}
+	for _, k := range r.keys {
+		app.logger.Printf("REPLACE\t%v <= %s", k, fxreflect.FuncName(a.Target))
+	}
+	app.provide(a)
+}
+
+// replaced reports whether a constructor provided to app must be skipped
+// because a replacement in scope overrides the values it produces.
+func (app *App) replaced(ctor interface{}) bool {
+	var replaced, kept []string
+	for _, k := range resultKeys(ctor) {
+		if r := app.replacementOf(k); r != nil {
+			r.used = true
+			replaced = append(replaced, k.String())
+		} else {
+			kept = append(kept, k.String())
+		}
+	}
+	if len(replaced) == 0 {
+		return false
+	}
+
+	if len(kept) > 0 && app.root().err == nil {
+		app.root().err = fmt.Errorf(
+			"cannot replace %v: %v also provides %v, which must be replaced too",
+			strings.Join(replaced, ", "), constructorName(ctor), strings.Join(kept, ", "))
+	}
+	return true
+}
+
+// replacementOf returns the replacement for k in app's scope, if any.
+func (app *App) replacementOf(k depKey) *replacement {
+	for ; app != nil; app = app.parent {
+		for _, r := range app.replaces {
+			for _, rk := range r.keys {
+				if rk == k {
+					return r
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkReplacements fails the application if a replacement in app or its
+// modules doesn't replace any constructor, or can't replace a constructor.
+func (app *App) checkReplacements() {
+	markReplaced(app)
+	reportUnusedReplacements(app)
+}
+
+// markReplaced records which replacements replace a constructor of app or
+// its modules.
+func markReplaced(app *App) {
+	for _, p := range app.provides {
+		app.replaced(p)
+	}
+	for _, ca := range app.children {
+		markReplaced(ca)
+	}
+}
+
+func reportUnusedReplacements(app *App) {
+	for _, r := range app.replaces {
+		if !r.used && app.root().err == nil {
+			keys := make([]string, len(r.keys))
+			for i, k := range r.keys {
+				keys[i] = k.String()
+			}
+			app.root().err = fmt.Errorf("cannot replace %v: no constructor in scope provides it",
+				strings.Join(keys, ", "))
+		}
+	}
+	for _, ca := range app.children {
+		reportUnusedReplacements(ca)
+	}
+}
+
+// constructorName describes a constructor provided to the application.
+func constructorName(ctor interface{}) string {
+	switch c := ctor.(type) {
+	case supplied:
+		return c.op + "() called from " + c.caller + "()"
+	case Annotated:
+		return fxreflect.FuncName(c.Target)
+	}
+	return fxreflect.FuncName(ctor)
+}
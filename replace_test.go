@@ -0,0 +1,129 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fx_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+func TestReplace(t *testing.T) {
+	type client struct{ name string }
+	newClient := func() *client {
+		t.Fatal("replaced constructor must not be called")
+		return nil
+	}
+
+	t.Run("Value", func(t *testing.T) {
+		var c *client
+		spy := &printerSpy{&bytes.Buffer{}}
+		app := fxtest.New(t,
+			fx.Logger(spy),
+			fx.Provide(newClient),
+			fx.Replace(&client{name: "fake"}),
+			fx.Populate(&c),
+		)
+		defer app.RequireStart().RequireStop()
+
+		assert.Equal(t, "fake", c.name)
+		assert.Contains(t, spy.String(), "REPLACE\t*fx_test.client <= fx.Replace() called from go.uber.org/fx_test.TestReplace")
+		assert.NotContains(t, spy.String(), "PROVIDE\t*fx_test.client")
+	})
+
+	t.Run("Constructor", func(t *testing.T) {
+		var c *client
+		newFake := func() *client { return &client{name: "fake"} }
+		spy := &printerSpy{&bytes.Buffer{}}
+		app := fxtest.New(t,
+			fx.Logger(spy),
+			fx.Provide(newClient),
+			fx.ReplaceProvide(newFake),
+			fx.Populate(&c),
+		)
+		defer app.RequireStart().RequireStop()
+
+		assert.Equal(t, "fake", c.name)
+		assert.Contains(t, spy.String(), "REPLACE\t*fx_test.client <= go.uber.org/fx_test.TestReplace")
+	})
+
+	t.Run("NamedAndGrouped", func(t *testing.T) {
+		type in struct {
+			fx.In
+
+			RO  *client   `name:"ro"`
+			All []*client `group:"all"`
+		}
+		var got in
+		app := fxtest.New(t,
+			fx.Provide(
+				fx.Annotated{Name: "ro", Target: newClient},
+				fx.Annotated{Group: "all", Target: newClient},
+				fx.Annotated{Group: "all", Target: newClient},
+			),
+			fx.Replace(
+				fx.Annotated{Name: "ro", Target: &client{name: "ro"}},
+				fx.Annotated{Group: "all", Target: &client{name: "all"}},
+			),
+			fx.Populate(&got),
+		)
+		defer app.RequireStart().RequireStop()
+
+		assert.Equal(t, "ro", got.RO.name)
+		require.Len(t, got.All, 1, "the replacement must be the only member of the group")
+		assert.Equal(t, "all", got.All[0].name)
+	})
+
+	t.Run("Module", func(t *testing.T) {
+		var c *client
+		app := fxtest.New(t,
+			fx.Module("kafka",
+				fx.Provide(newClient),
+				fx.Replace(&client{name: "fake"}),
+			),
+			fx.Populate(&c),
+		)
+		defer app.RequireStart().RequireStop()
+		assert.Equal(t, "fake", c.name)
+
+		app2 := fx.New(fx.NopLogger,
+			fx.Provide(newClient),
+			fx.Module("tests", fx.Replace(&client{name: "fake"})),
+		)
+		require.Error(t, app2.Err(), "a module can't replace constructors outside of it")
+		assert.Contains(t, app2.Err().Error(), "cannot replace *fx_test.client: no constructor in scope provides it")
+	})
+
+	t.Run("OtherResults", func(t *testing.T) {
+		newBoth := func() (*client, *bytes.Buffer) { return nil, nil }
+		app := fx.New(fx.NopLogger,
+			fx.Provide(newBoth),
+			fx.Replace(&client{}),
+		)
+		require.Error(t, app.Err())
+		assert.Contains(t, app.Err().Error(), "which must be replaced too")
+		assert.Contains(t, app.Err().Error(), "also provides *bytes.Buffer")
+	})
+}
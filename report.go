@@ -0,0 +1,55 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fx
+
+import "go.uber.org/fx/internal/lifecycle"
+
+// A LifecycleReport records how long an application took to start or stop:
+// the duration of each Lifecycle hook callback, of each phase (for example,
+// running all OnStart hooks), and in total.
+type LifecycleReport = lifecycle.Report
+
+// A HookTiming records how long a Lifecycle hook callback took.
+type HookTiming = lifecycle.HookTiming
+
+// A PhaseTiming records how long a phase of starting or stopping an
+// application took.
+type PhaseTiming = lifecycle.PhaseTiming
+
+// StartupReport returns the timings of the last call to Start. Tests can use
+// it to fail when startup gets slower than expected:
+//
+//	app.RequireStart()
+//	if d := app.StartupReport().Duration; d > time.Second {
+//		t.Errorf("startup took %v", d)
+//	}
+//
+// The report is incomplete while Start runs, or if Start timed out.
+func (app *App) StartupReport() LifecycleReport {
+	return app.lifecycle.StartReport()
+}
+
+// ShutdownReport returns the timings of the last call to Stop, including the
+// Stop that rolls back a failed Start. The report is incomplete while Stop
+// runs, or if Stop timed out.
+func (app *App) ShutdownReport() LifecycleReport {
+	return app.lifecycle.StopReport()
+}
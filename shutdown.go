@@ -24,6 +24,9 @@ import (
 	"fmt"
 	"os"
 	"syscall"
+	"time"
+
+	"go.uber.org/fx/internal/fxreflect"
 )
 
 // Shutdowner provides a method that can manually trigger the shutdown of the
@@ -35,26 +38,156 @@ type Shutdowner interface {
 }
 
 // ShutdownOption provides a way to configure properties of the shutdown
-// process. Currently, no options have been implemented.
+// process, such as the exit code of an application using Run.
+//
+// If Shutdown is called several times, each property keeps the value set by
+// the first call that sets it, until the application is started again.
 type ShutdownOption interface {
-	apply(*shutdowner)
+	apply(*shutdownRequest)
+}
+
+type exitCodeOption int
+
+func (code exitCodeOption) apply(r *shutdownRequest) {
+	r.exitCode = int(code)
+}
+
+// ExitCode sets the exit code of an application using Run. Once the
+// application has stopped, Run exits the process with this code if it isn't
+// zero.
+func ExitCode(code int) ShutdownOption {
+	return exitCodeOption(code)
+}
+
+type shutdownReasonOption struct{ err error }
+
+func (o shutdownReasonOption) apply(r *shutdownRequest) {
+	r.reason = o.err
+}
+
+// ShutdownReason records why the application is shutting down. Run logs it.
+func ShutdownReason(err error) ShutdownOption {
+	return shutdownReasonOption{err}
+}
+
+type shutdownTimeoutOption time.Duration
+
+func (d shutdownTimeoutOption) apply(r *shutdownRequest) {
+	r.timeout = time.Duration(d)
+}
+
+// ShutdownTimeout overrides the application's StopTimeout for this shutdown
+// of an application using Run.
+func ShutdownTimeout(d time.Duration) ShutdownOption {
+	return shutdownTimeoutOption(d)
+}
+
+// shutdownRequest holds the properties of a shutdown set by ShutdownOptions.
+type shutdownRequest struct {
+	exitCode int
+	reason   error
+	timeout  time.Duration
+}
+
+// merge sets the properties of r that aren't set yet from o.
+func (r *shutdownRequest) merge(o shutdownRequest) {
+	if r.exitCode == 0 {
+		r.exitCode = o.exitCode
+	}
+	if r.reason == nil {
+		r.reason = o.reason
+	}
+	if r.timeout == 0 {
+		r.timeout = o.timeout
+	}
 }
 
 type shutdowner struct {
 	app *App
 }
 
-// Shutdown broadcasts a signal to all of the application's Done channels
-// and begins the Stop process.
+// Shutdown broadcasts a signal to all of the application's Done and Wait
+// channels and begins the Stop process.
 func (s *shutdowner) Shutdown(opts ...ShutdownOption) error {
-	return s.app.broadcastSignal(syscall.SIGTERM)
+	return s.app.shutdown(fxreflect.Caller(), opts...)
+}
+
+// shutdown shuts the application down on behalf of caller.
+func (app *App) shutdown(caller string, opts ...ShutdownOption) error {
+	var r shutdownRequest
+	for _, opt := range opts {
+		opt.apply(&r)
+	}
+
+	app.shutdownMu.Lock()
+	app.shutdownReq.merge(r)
+	app.shutdownMu.Unlock()
+
+	return app.broadcastSignal(ShutdownSignal{
+		Reason:   r.reason,
+		ExitCode: r.exitCode,
+		Caller:   caller,
+	})
 }
 
+// shutdownRequest returns the properties set by calls to Shutdown since the
+// application was last started.
+func (app *App) shutdownRequest() shutdownRequest {
+	app.shutdownMu.Lock()
+	defer app.shutdownMu.Unlock()
+	return app.shutdownReq
+}
+
+// An ExitError is returned by RunE when the application should exit with a
+// non-zero code without having failed to start or stop.
+type ExitError struct {
+	// Code is the exit code: the one passed to Shutdown with the ExitCode
+	// option, or the one set by ForceExitCode if the exit was forced.
+	Code int
+
+	// Reason is the error passed to Shutdown with the ShutdownReason option,
+	// if any.
+	Reason error
+
+	// Forced reports whether a second shutdown signal forced the application
+	// to exit before it stopped.
+	Forced bool
+}
+
+func (e *ExitError) Error() string {
+	msg := fmt.Sprintf("exit code %d", e.Code)
+	if e.Forced {
+		msg = "forced to exit with " + msg
+	}
+	if e.Reason != nil {
+		msg += ": " + e.Reason.Error()
+	}
+	return msg
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Reason
+}
+
+// _exit exits the process with the given code. Tests replace it.
+var _exit = os.Exit
+
 func (app *App) shutdowner() Shutdowner {
 	return &shutdowner{app: app}
 }
 
-func (app *App) broadcastSignal(signal os.Signal) error {
+// broadcastSignal sends a shutdown signal to all of the application's Wait
+// channels, and the corresponding OS signal to all of its Done channels:
+// SIGTERM if the shutdown wasn't caused by an OS signal.
+func (app *App) broadcastSignal(s ShutdownSignal) error {
+	signal := s.Signal
+	if signal == nil {
+		signal = syscall.SIGTERM
+	}
+
+	app.cancelContext()
+	app.interrupt(s)
+
 	app.donesMu.RLock()
 	defer app.donesMu.RUnlock()
 
@@ -68,10 +201,17 @@ func (app *App) broadcastSignal(signal os.Signal) error {
 			unsent++
 		}
 	}
+	for _, wait := range app.waits {
+		select {
+		case wait <- s:
+		default:
+			unsent++
+		}
+	}
 
 	if unsent != 0 {
 		return fmt.Errorf("failed to send %v signal to %v out of %v channels",
-			signal, unsent, len(app.dones),
+			signal, unsent, len(app.dones)+len(app.waits),
 		)
 	}
 
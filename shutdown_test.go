@@ -21,10 +21,14 @@
 package fx_test
 
 import (
+	"errors"
+	"os"
 	"syscall"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/fx"
 	"go.uber.org/fx/fxtest"
 )
@@ -60,4 +64,57 @@ func TestShutdown(t *testing.T) {
 			"unexpected error returned when shutdown is called with a blocked channel")
 		assert.Equal(t, syscall.SIGTERM, <-done, "done channel did not receive signal")
 	})
+	t.Run("Wait", func(t *testing.T) {
+		var s fx.Shutdowner
+		app := fxtest.New(
+			t,
+			fx.Populate(&s),
+		)
+
+		wait, done := app.Wait(), app.Done()
+		defer app.RequireStart().RequireStop()
+
+		reason := errors.New("lost connection to the database")
+		require.NoError(t, s.Shutdown(fx.ExitCode(3), fx.ShutdownReason(reason)))
+
+		sig := <-wait
+		assert.Nil(t, sig.Signal)
+		assert.Equal(t, reason, sig.Reason)
+		assert.Equal(t, 3, sig.ExitCode)
+		assert.Contains(t, sig.Caller, "TestShutdown")
+		assert.Equal(t, syscall.SIGTERM, <-done, "done channel did not receive signal")
+	})
+
+	t.Run("WaitForOSSignal", func(t *testing.T) {
+		app := fxtest.New(t)
+		wait := app.Wait()
+		defer app.RequireStart().RequireStop()
+
+		p, err := os.FindProcess(os.Getpid())
+		require.NoError(t, err)
+		if err := p.Signal(syscall.SIGINT); err != nil {
+			t.Skipf("can't send SIGINT: %v", err)
+		}
+
+		select {
+		case sig := <-wait:
+			assert.Equal(t, fx.ShutdownSignal{Signal: syscall.SIGINT}, sig)
+		case <-time.After(time.Second):
+			t.Fatal("wait channel did not receive signal")
+		}
+	})
+
+	t.Run("ErrorOnUnsentWait", func(t *testing.T) {
+		var s fx.Shutdowner
+		app := fxtest.New(
+			t,
+			fx.Populate(&s),
+		)
+
+		app.Done()
+		app.Wait()
+		defer app.RequireStart().RequireStop()
+		require.NoError(t, s.Shutdown(), "error returned from first shutdown call")
+		assert.EqualError(t, s.Shutdown(), "failed to send terminated signal to 2 out of 2 channels")
+	})
 }
@@ -0,0 +1,127 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fx
+
+import (
+	"os"
+	"os/signal"
+)
+
+// A ShutdownSignal describes why an application is shutting down. It's
+// delivered by the channels returned by App.Wait.
+type ShutdownSignal struct {
+	// Signal is the OS signal that was received, or nil if the shutdown
+	// was requested with the Shutdowner.
+	Signal os.Signal
+
+	// Reason and ExitCode are the values passed to Shutdown with the
+	// ShutdownReason and ExitCode options, if any.
+	Reason   error
+	ExitCode int
+
+	// Caller is the function that called Shutdown, or the function run with
+	// Go whose failure shut the application down. It's empty if an OS
+	// signal was received.
+	Caller string
+}
+
+// Wait returns a channel to block on after starting the application. It
+// receives a ShutdownSignal when the application receives one of its
+// shutdown signals (see ShutdownSignals), or when the Shutdowner is used,
+// telling apart the two and carrying the options passed to Shutdown.
+//
+// Like Done, each call returns a new channel, buffered to hold a single
+// signal.
+func (app *App) Wait() <-chan ShutdownSignal {
+	app.relaySignals()
+
+	c := make(chan ShutdownSignal, 1)
+	app.donesMu.Lock()
+	app.waits = append(app.waits, c)
+	app.donesMu.Unlock()
+	return c
+}
+
+// ShutdownSignals sets the OS signals that shut down the application, by
+// default SIGINT and SIGTERM. The signals are received by the channels
+// returned by Done and Wait. Without any signals, OS signal handling is
+// disabled, as with DisableSignalHandling.
+func ShutdownSignals(signals ...os.Signal) Option {
+	return optionFunc(func(app *App) {
+		app.signals = signals
+	})
+}
+
+// DisableSignalHandling stops the application from listening for OS signals,
+// including reload signals, leaving their handling to the program. This is
+// useful for applications embedded in other programs, and in tests. The
+// channels returned by Done and Wait then only receive the signals sent with
+// the Shutdowner.
+func DisableSignalHandling() Option {
+	return optionFunc(func(app *App) {
+		app.signals = nil
+		app.reloadSignals = nil
+	})
+}
+
+// relaySignals starts relaying the application's shutdown signals to its
+// Done and Wait channels, unless it's already doing so or signal handling is
+// disabled.
+func (app *App) relaySignals() {
+	app.signalsMu.Lock()
+	defer app.signalsMu.Unlock()
+
+	if app.sigc != nil || len(app.signals) == 0 {
+		return
+	}
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, app.signals...)
+	go func() {
+		for sig := range c {
+			app.broadcastSignal(ShutdownSignal{Signal: sig})
+		}
+	}()
+	app.sigc = c
+}
+
+// stopRelayingSignals undoes relaySignals, restoring the default handling of
+// the application's shutdown signals unless the program handles them
+// elsewhere.
+func (app *App) stopRelayingSignals() {
+	app.signalsMu.Lock()
+	defer app.signalsMu.Unlock()
+
+	if app.sigc == nil {
+		return
+	}
+	// Once Stop returns, no more signals are sent on the channel.
+	signal.Stop(app.sigc)
+	close(app.sigc)
+	app.sigc = nil
+}
+
+// hasSignalChannels reports whether Done or Wait were called.
+func (app *App) hasSignalChannels() bool {
+	app.donesMu.RLock()
+	defer app.donesMu.RUnlock()
+	return len(app.dones)+len(app.waits) > 0
+}
@@ -0,0 +1,181 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fx
+
+import "fmt"
+
+// State describes where an App is in its lifecycle.
+//
+// An App begins in StateCreated. Start moves it through StateStarting to
+// StateRunning, or to StateStopped if any OnStart hook fails or Start times
+// out. Stop moves a running App through StateStopping to StateStopped, from
+// which Start may start it again.
+type State int
+
+const (
+	// StateCreated is the state of an App returned by New.
+	StateCreated State = iota
+	// StateStarting is the state of an App while its OnStart hooks run.
+	StateStarting
+	// StateRunning is the state of an App whose OnStart hooks all
+	// succeeded.
+	StateRunning
+	// StateStopping is the state of an App while its OnStop hooks run.
+	StateStopping
+	// StateStopped is the state of an App that was stopped or failed to
+	// start.
+	StateStopped
+)
+
+func (s State) String() string {
+	switch s {
+	case StateCreated:
+		return "created"
+	case StateStarting:
+		return "starting"
+	case StateRunning:
+		return "running"
+	case StateStopping:
+		return "stopping"
+	case StateStopped:
+		return "stopped"
+	default:
+		return fmt.Sprintf("State(%d)", int(s))
+	}
+}
+
+// StateError is returned by Start and Stop if the App isn't in a state that
+// allows the operation. This includes calling Start or Stop while another
+// goroutine is already starting or stopping the App.
+type StateError struct {
+	// Op is the attempted operation: "start" or "stop".
+	Op string
+
+	// State is the App's state at the time of the call.
+	State State
+}
+
+func (e *StateError) Error() string {
+	return fmt.Sprintf("fx: cannot %s application: application is %v", e.Op, e.State)
+}
+
+// State returns the App's current state.
+func (app *App) State() State {
+	app.stateMu.Lock()
+	defer app.stateMu.Unlock()
+	return app.state
+}
+
+// StateChanges returns a channel that receives each state the App enters
+// after the call. The channel is buffered, but sends never block: a receiver
+// that falls behind misses transitions, so it should use State to learn the
+// current state.
+func (app *App) StateChanges() <-chan State {
+	c := make(chan State, _stateChangesBuffer)
+
+	app.stateMu.Lock()
+	app.stateSubs = append(app.stateSubs, c)
+	app.stateMu.Unlock()
+	return c
+}
+
+// Enough to hold a full start and stop cycle.
+const _stateChangesBuffer = 4
+
+// transition moves the App to the given state if it's currently in one of
+// the from states, and returns a StateError otherwise.
+func (app *App) transition(op string, to State, from ...State) error {
+	app.stateMu.Lock()
+	defer app.stateMu.Unlock()
+
+	for _, s := range from {
+		if app.state == s {
+			app.setStateLocked(to)
+			return nil
+		}
+	}
+	return &StateError{Op: op, State: app.state}
+}
+
+func (app *App) setState(s State) {
+	app.stateMu.Lock()
+	defer app.stateMu.Unlock()
+	app.setStateLocked(s)
+}
+
+func (app *App) setStateLocked(s State) {
+	app.state = s
+	for _, c := range app.stateSubs {
+		select {
+		case c <- s:
+		default:
+		}
+	}
+}
+
+// finishStart moves the App to StateRunning once its OnStart hooks succeed.
+// It reports false if Start already gave up on the hooks, in which case the
+// App must roll back instead.
+func (app *App) finishStart() bool {
+	app.stateMu.Lock()
+	defer app.stateMu.Unlock()
+
+	if app.startAbandoned != nil {
+		return false
+	}
+	app.setStateLocked(StateRunning)
+	return true
+}
+
+// abandonStart records that Start gave up waiting for the OnStart hooks, so
+// that the App rolls back once they return. The done channel is closed once
+// it has. abandonStart reports false if the start already finished.
+func (app *App) abandonStart(done chan struct{}) bool {
+	app.stateMu.Lock()
+	defer app.stateMu.Unlock()
+
+	if app.state != StateStarting {
+		return false
+	}
+	app.startAbandoned = done
+	return true
+}
+
+// abandonedStart returns a channel closed once the App rolls back the start
+// that Start gave up on, or nil if it isn't waiting for one.
+func (app *App) abandonedStart() <-chan struct{} {
+	app.stateMu.Lock()
+	defer app.stateMu.Unlock()
+
+	if app.state != StateStarting {
+		return nil
+	}
+	return app.startAbandoned
+}
+
+// rolledBack moves the App to StateStopped once a start has rolled back.
+func (app *App) rolledBack() {
+	app.stateMu.Lock()
+	defer app.stateMu.Unlock()
+
+	app.startAbandoned = nil
+	app.setStateLocked(StateStopped)
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fx
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go.uber.org/fx/internal/fxreflect"
+)
+
+// Supply provides instantiated values for dependency injection as if they
+// had been provided using a constructor that simply returns them. For
+// example,
+//
+//	var cfg *Config = ...
+//	fx.Supply(cfg)
+//
+// is equivalent to
+//
+//	fx.Provide(func() *Config { return cfg })
+//
+// Values are provided with their concrete type. To give a value a name or
+// add it to a group, wrap it in an Annotated with the value as the Target:
+//
+//	fx.Supply(fx.Annotated{Name: "ro", Target: roConn})
+//
+// Supply can't provide untyped nils or errors.
+func Supply(values ...interface{}) Option {
+	return supplyOption{values: values, caller: fxreflect.Caller()}
+}
+
+type supplyOption struct {
+	values []interface{}
+	caller string
+}
+
+func (o supplyOption) apply(app *App) {
+	for _, v := range o.values {
+		app.provides = append(app.provides, supplied{value: v, op: "fx.Supply", caller: o.caller})
+	}
+}
+
+func (o supplyOption) String() string {
+	items := make([]string, len(o.values))
+	for i, v := range o.values {
+		if a, ok := v.(Annotated); ok {
+			v = a.Target
+		}
+		items[i] = fmt.Sprint(reflect.TypeOf(v))
+	}
+	return fmt.Sprintf("fx.Supply(%s)", strings.Join(items, ", "))
+}
+
+// supplied is a value passed to Supply or Replace, which is provided in
+// place of a constructor.
+type supplied struct {
+	value  interface{}
+	op     string // "fx.Supply" or "fx.Replace"
+	caller string
+}
+
+// supply provides a value passed to Supply or Replace, through a constructor
+// that returns it.
+func (app *App) supply(s supplied) {
+	a, ok := s.value.(Annotated)
+	if !ok {
+		a = Annotated{Target: s.value}
+	}
+
+	t := reflect.TypeOf(a.Target)
+	switch {
+	case t == nil:
+		app.err = fmt.Errorf("%s received an untyped nil from %s()", s.op, s.caller)
+		return
+	case t.Implements(_typeOfError):
+		app.err = fmt.Errorf("%s received an error from %s(): %v", s.op, s.caller, a.Target)
+		return
+	}
+
+	label := strings.ToUpper(strings.TrimPrefix(s.op, "fx."))
+	key := depKey{t: t, name: a.Name, group: a.Group}
+	app.logger.Printf("%s\t%v <= %s() called from %s()", label, key, s.op, s.caller)
+
+	value := reflect.ValueOf(a.Target)
+	a.Target = reflect.MakeFunc(
+		reflect.FuncOf(nil, []reflect.Type{t}, false),
+		func([]reflect.Value) []reflect.Value { return []reflect.Value{value} },
+	).Interface()
+	app.provideAnnotated(a, s.caller)
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fx_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+func TestSupply(t *testing.T) {
+	type A struct{ name string }
+	type B struct{ name string }
+
+	t.Run("Values", func(t *testing.T) {
+		var (
+			a *A
+			b B
+		)
+		spy := &printerSpy{&bytes.Buffer{}}
+		app := fxtest.New(t,
+			fx.Logger(spy),
+			fx.Supply(&A{name: "a"}, B{name: "b"}),
+			fx.Populate(&a, &b),
+		)
+		defer app.RequireStart().RequireStop()
+
+		assert.Equal(t, "a", a.name)
+		assert.Equal(t, "b", b.name)
+		assert.Regexp(t, `SUPPLY\t\*fx_test.A <= fx.Supply\(\) called from go.uber.org/fx_test.TestSupply.func1\(\)`, spy.String())
+		assert.Contains(t, spy.String(), "SUPPLY\tfx_test.B <= fx.Supply()")
+	})
+
+	t.Run("Annotated", func(t *testing.T) {
+		type in struct {
+			fx.In
+
+			RO  *A   `name:"ro"`
+			All []*B `group:"all"`
+		}
+		var got in
+		spy := &printerSpy{&bytes.Buffer{}}
+		app := fxtest.New(t,
+			fx.Logger(spy),
+			fx.Supply(
+				fx.Annotated{Name: "ro", Target: &A{name: "ro"}},
+				fx.Annotated{Group: "all", Target: &B{name: "1"}},
+				fx.Annotated{Group: "all", Target: &B{name: "2"}},
+			),
+			fx.Populate(&got),
+		)
+		defer app.RequireStart().RequireStop()
+
+		assert.Equal(t, "ro", got.RO.name)
+		assert.Len(t, got.All, 2)
+		assert.Contains(t, spy.String(), "SUPPLY\t*fx_test.A:ro <= fx.Supply()")
+		assert.Contains(t, spy.String(), "SUPPLY\t*fx_test.B[group:all] <= fx.Supply()")
+	})
+
+	t.Run("AutoLifecycle", func(t *testing.T) {
+		var events []string
+		spy := &bytes.Buffer{}
+		app := fxtest.New(t,
+			fx.Logger(log.New(spy, "", 0)),
+			fx.AutoLifecycle(),
+			fx.Supply(&autoServer{&events, "server"}),
+			fx.Invoke(func(*autoServer) {}),
+		)
+		app.RequireStart().RequireStop()
+
+		assert.Equal(t, []string{"start server", "stop server"}, events)
+		assert.Contains(t, spy.String(), "START\t\tgo.uber.org/fx_test.TestSupply.func")
+		assert.NotContains(t, spy.String(), "makeFuncStub")
+	})
+
+	t.Run("InvalidValues", func(t *testing.T) {
+		app := fx.New(fx.NopLogger, fx.Supply(nil))
+		require.Error(t, app.Err())
+		assert.Contains(t, app.Err().Error(), "fx.Supply received an untyped nil from go.uber.org/fx_test.TestSupply")
+
+		app = fx.New(fx.NopLogger, fx.Supply(errors.New("great sadness")))
+		require.Error(t, app.Err())
+		assert.Contains(t, app.Err().Error(), "fx.Supply received an error")
+	})
+
+	t.Run("String", func(t *testing.T) {
+		opt := fx.Supply(&A{}, fx.Annotated{Name: "b", Target: B{}})
+		assert.Equal(t, "fx.Supply(*fx_test.A, fx_test.B)", fmt.Sprint(opt))
+	})
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fx
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/fx/internal/lifecycle"
+)
+
+// A StuckHook describes a Lifecycle hook that was still running when the
+// application gave up waiting for it to start or stop.
+type StuckHook = lifecycle.StuckHook
+
+// A TimeoutError is returned by Start and Stop if their context expires while
+// Lifecycle hooks are still running. It reports the hooks that were running,
+// with their stack traces, and wraps the context's error, so
+// errors.Is(err, context.DeadlineExceeded) still holds.
+type TimeoutError struct {
+	// Err is the error of the expired context.
+	Err error
+
+	// Hooks are the hooks that were running, longest running first.
+	Hooks []StuckHook
+}
+
+func (e *TimeoutError) Error() string {
+	hooks := make([]string, len(e.Hooks))
+	for i, h := range e.Hooks {
+		hooks[i] = h.String()
+	}
+	return fmt.Sprintf("%v: %s", e.Err, strings.Join(hooks, "; "))
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// withTimeout runs f, giving up once ctx expires. If it gives up while
+// Lifecycle hooks are running, it logs them along with their stack traces
+// and returns a *TimeoutError.
+//
+// Before giving up, withTimeout calls abandon, if any. If abandon reports
+// that f can no longer be abandoned, withTimeout waits for f instead.
+func (app *App) withTimeout(ctx context.Context, f func(context.Context) error, abandon func() bool) error {
+	c := make(chan error, 1)
+	go func() { c <- f(ctx) }()
+
+	select {
+	case err := <-c:
+		return err
+	case <-ctx.Done():
+	}
+	if abandon != nil && !abandon() {
+		return <-c
+	}
+
+	hooks := app.lifecycle.StuckHooks()
+	if len(hooks) == 0 {
+		return ctx.Err()
+	}
+	for _, h := range hooks {
+		app.logger.Printf("ERROR\t\t%v:\n%s", h, h.Stack)
+	}
+	return &TimeoutError{Err: ctx.Err(), Hooks: hooks}
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fx
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/fx/internal/lifecycle"
+)
+
+// Go registers a long-running function, such as a server's listen loop or a
+// queue consumer, that runs in its own goroutine while the application is
+// running. It's equivalent to appending a Hook to lc whose OnStart spawns the
+// goroutine and whose OnStop cancels the function's context and waits for it
+// to return.
+//
+// The function should return once its context is canceled. If it returns an
+// error before that, the error is logged and the application is shut down
+// with the error as its ShutdownReason; the error is also returned by Stop.
+// Options such as RestartOnFailure restart the function instead. The name
+// identifies the function in logs and errors.
+//
+// With a Lifecycle other than the application's, such as an fxtest.Lifecycle,
+// the function's failures are only reported by Stop.
+func Go(lc Lifecycle, name string, run func(context.Context) error, opts ...WorkerOption) {
+	var l *lifecycle.Lifecycle
+	if w, ok := lc.(*lifecycleWrapper); ok {
+		l = w.Lifecycle
+	}
+	policy := make([]lifecycle.WorkerOption, len(opts))
+	for i, opt := range opts {
+		policy[i] = opt.apply
+	}
+	h := l.Worker(name, run, policy...)
+	lc.Append(Hook{OnStart: h.OnStart, OnStop: h.OnStop})
+}
+
+// A WorkerOption configures how a function registered with Go is
+// supervised.
+//
+// By default, such functions are never restarted: if one returns an error
+// before the application stops it, the application shuts down. With a
+// restart policy, the function is restarted instead, until the policy is
+// exhausted.
+type WorkerOption interface {
+	apply(*lifecycle.RestartPolicy)
+}
+
+type restartModeOption lifecycle.RestartMode
+
+func (m restartModeOption) apply(p *lifecycle.RestartPolicy) {
+	p.Mode = lifecycle.RestartMode(m)
+}
+
+// RestartOnFailure restarts the function whenever it returns an error before
+// being stopped. If it returns nil, it isn't restarted.
+func RestartOnFailure() WorkerOption {
+	return restartModeOption(lifecycle.RestartOnFailure)
+}
+
+// RestartAlways restarts the function whenever it returns before being
+// stopped, even if it returns nil.
+func RestartAlways() WorkerOption {
+	return restartModeOption(lifecycle.RestartAlways)
+}
+
+type restartBackoffOption struct{ min, max time.Duration }
+
+func (o restartBackoffOption) apply(p *lifecycle.RestartPolicy) {
+	p.MinBackoff = o.min
+	p.MaxBackoff = o.max
+}
+
+// RestartBackoff configures the exponential backoff between restarts: the
+// first restart waits for min, and each further restart counted by
+// MaxRestarts waits twice as long as the previous one, up to max. Without a
+// MaxRestarts window, the backoff starts over once the function runs for
+// longer than max. Without this option, restarts back off from 100
+// milliseconds to 30 seconds.
+func RestartBackoff(min, max time.Duration) WorkerOption {
+	return restartBackoffOption{min, max}
+}
+
+type maxRestartsOption struct {
+	n      int
+	window time.Duration
+}
+
+func (o maxRestartsOption) apply(p *lifecycle.RestartPolicy) {
+	p.MaxRestarts = o.n
+	p.Window = o.window
+}
+
+// MaxRestarts gives up on restarting the function once it has been
+// restarted n times within the given window; a zero window counts all
+// restarts. When the function gives up, the application shuts down and Stop
+// returns an error naming the function.
+func MaxRestarts(n int, window time.Duration) WorkerOption {
+	return maxRestartsOption{n, window}
+}